@@ -2,9 +2,13 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/rand"
+	"sort"
+	"time"
 
 	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/reactor"
 	"github.com/farseeingnorthwest/battleground.go/storage"
 	"github.com/farseeingnorthwest/playground/battlefield/v2"
 	"github.com/gofiber/fiber/v2"
@@ -13,38 +17,50 @@ import (
 type BattleController struct {
 	CharacterRepo CharacterRepository
 	skillRepo     SkillRepository
+	itemRepo      ItemRepository
+	rules         CharacterRules
 }
 
-func NewBattleController(characterRepo CharacterRepository, skillRepo SkillRepository) BattleController {
-	return BattleController{characterRepo, skillRepo}
+func NewBattleController(characterRepo CharacterRepository, skillRepo SkillRepository, itemRepo ItemRepository, rules CharacterRules) BattleController {
+	return BattleController{characterRepo, skillRepo, itemRepo, rules}
 }
 
 func (c BattleController) Mount(router fiber.Router) {
 	router.Post("/battles", c.CreateBattle)
 }
 
+// CreateBattle runs the battle, sending along the key of its snapshot in
+// X-Battle-Snapshot: a hash over the deadline, and the warriors and reactors
+// on the field by their contents, which is the same for battles set up the
+// same whatever the skills and characters are named. The seed is left out,
+// for the field is run with JustRng whatever it is.
 func (c BattleController) CreateBattle(fc *fiber.Ctx) error {
 	form := struct {
 		Seed     int64
-		Left     map[int]int
-		Right    map[int]int
+		Left     map[int]warriorForm
+		Right    map[int]warriorForm
 		Ground   []int
 		Deadline int
+		AsOf     string `json:"as_of"`
 	}{}
 	if err := fc.BodyParser(&form); err != nil {
 		return err
 	}
+	asOf, err := parseAsOf(form.AsOf)
+	if err != nil {
+		return err
+	}
 
-	skills, err := c.getSkills()
+	skills, err := c.getSkills(asOf)
 	if err != nil {
 		return err
 	}
 
-	left, err := c.getWarriors(form.Left, battlefield.Left, skills)
+	left, leftSnapshot, err := c.getWarriors(form.Left, battlefield.Left, skills, asOf)
 	if err != nil {
 		return err
 	}
-	right, err := c.getWarriors(form.Right, battlefield.Right, skills)
+	right, rightSnapshot, err := c.getWarriors(form.Right, battlefield.Right, skills, asOf)
 	if err != nil {
 		return err
 	}
@@ -64,9 +80,24 @@ func (c BattleController) CreateBattle(fc *fiber.Ctx) error {
 		battlefield.FieldReactor(ob),
 		battlefield.FieldReactor(newObserverComplete(ob)),
 	}
-	for _, id := range form.Ground {
+	ground := make([]string, len(form.Ground))
+	for i, id := range form.Ground {
 		opts = append(opts, battlefield.FieldReactor(skills[id].Reactor.Spawn()))
+		if ground[i], err = skills[id].Hash(); err != nil {
+			return err
+		}
+	}
+
+	snapshot, err := reactor.Hash(map[string]any{
+		"deadline": deadline,
+		"ground":   ground,
+		"left":     leftSnapshot,
+		"right":    rightSnapshot,
+	})
+	if err != nil {
+		return err
 	}
+	fc.Set("X-Battle-Snapshot", snapshot)
 
 	f := battlefield.NewBattleField(rng, append(left, right...), opts...)
 	f.Run()
@@ -74,8 +105,8 @@ func (c BattleController) CreateBattle(fc *fiber.Ctx) error {
 	return fc.JSON(ob)
 }
 
-func (c BattleController) getSkills() (map[int]storage.Skill, error) {
-	skills, err := c.skillRepo.FindEx()
+func (c BattleController) getSkills(asOf time.Time) (map[int]storage.Skill, error) {
+	skills, err := c.skillRepo.FindExAsOf(asOf)
 	if err != nil {
 		return nil, err
 	}
@@ -83,36 +114,170 @@ func (c BattleController) getSkills() (map[int]storage.Skill, error) {
 	return functional.Tabulate[int, storage.Skill](bySkillID(skills)), nil
 }
 
-func (c BattleController) getWarriors(m map[int]int, side battlefield.Side, skills map[int]storage.Skill) ([]battlefield.Warrior, error) {
-	charSlice, err := c.CharacterRepo.Find(functional.Values(m)...)
+// getWarriors puts the characters on their side of the field, along with a
+// snapshot of each by position: its baseline, tags and the content hashes of
+// its skills.
+func (c BattleController) getWarriors(m map[int]warriorForm, side battlefield.Side, skills map[int]storage.Skill, asOf time.Time) ([]battlefield.Warrior, map[int]any, error) {
+	for _, w := range m {
+		if err := c.rules.checkLevel(w.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+	charSlice, err := c.CharacterRepo.FindAsOf(asOf, functional.MapSlice(func(w warriorForm) int {
+		return w.Character
+	}, functional.Values(m))...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	loadouts, items, err := c.getLoadouts(m, asOf)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	warriors := make([]battlefield.Warrior, 0, len(m))
+	snapshots := make(map[int]any, len(m))
 	chars := functional.Tabulate[int, storage.Character](byCharacterID(charSlice))
-	for p, id := range m {
-		warriors = append(warriors, battlefield.NewMyWarrior(
-			battlefield.MyBaseline{
-				Damage:       chars[id].Damage,
-				CriticalOdds: chars[id].CriticalOdds,
-				CriticalLoss: chars[id].CriticalLoss,
-				Defense:      chars[id].Defense,
-				Health:       chars[id].Health,
-				Speed:        chars[id].Speed,
+	for p, w := range m {
+		id := w.Character
+		tags, err := warriorTags(chars[id].Tags)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		char := chars[id].AtLevel(w.Level).Equip(functional.MapSlice(func(item int) storage.Item {
+			return items[item]
+		}, loadouts[p])...)
+		held := functional.MapSlice(
+			func(meta storage.SkillMeta) storage.Skill {
+				return skills[meta.ID]
 			},
+			functional.Values(chars[id].Skills),
+		)
+		for _, item := range loadouts[p] {
+			for _, meta := range items[item].Skills {
+				skill, ok := skills[meta.ID]
+				if !ok {
+					return nil, nil, fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("item %d holds unknown skill %d", item, meta.ID))
+				}
+				held = append(held, skill)
+			}
+		}
+		reactors := functional.MapSlice(func(skill storage.Skill) battlefield.Reactor {
+			return skill.Reactor.Spawn()
+		}, held)
+		hashes, err := skillHashes(held)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		baseline := battlefield.MyBaseline{
+			Damage:       char.Damage,
+			CriticalOdds: char.CriticalOdds,
+			CriticalLoss: char.CriticalLoss,
+			Defense:      char.Defense,
+			Health:       char.Health,
+			Speed:        char.Speed,
+		}
+		warriors = append(warriors, battlefield.NewMyWarrior(
+			baseline,
 			side,
 			p,
-			battlefield.WarriorSkills(functional.MapSlice(
-				func(meta storage.SkillMeta) battlefield.Reactor {
-					return skills[meta.ID].Reactor.Spawn()
-				},
-				functional.Values(chars[id].Skills),
-			)...),
+			battlefield.WarriorSkills(reactors...),
+			battlefield.WarriorTags(tags...),
 		))
+		snapshots[p] = map[string]any{
+			"baseline": baseline,
+			"tags":     chars[id].Tags,
+			"skills":   hashes,
+		}
+	}
+
+	return warriors, snapshots, nil
+}
+
+// skillHashes are the content hashes of the skills, in order, for the order
+// the skills are held in does not matter.
+func skillHashes(skills []storage.Skill) ([]string, error) {
+	hashes := make([]string, len(skills))
+	for i, skill := range skills {
+		var err error
+		if hashes[i], err = skill.Hash(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(hashes)
+
+	return hashes, nil
+}
+
+// getLoadouts resolves the items worn by the warriors by position: the
+// ones listed by the form, or else the ones the character is equipped with.
+// Neither items nor equipment are kept as of a time, so warriors of the past
+// wear nothing, and listing items for them is rejected.
+func (c BattleController) getLoadouts(m map[int]warriorForm, asOf time.Time) (map[int][]int, map[int]storage.Item, error) {
+	if !asOf.IsZero() {
+		for _, w := range m {
+			if w.Items != nil && len(*w.Items) > 0 {
+				return nil, nil, fiber.NewError(fiber.StatusUnprocessableEntity, "items cannot be worn as of a time")
+			}
+		}
+		return nil, nil, nil
+	}
+
+	var equipped []int
+	for _, w := range m {
+		if w.Items == nil {
+			equipped = append(equipped, w.Character)
+		}
+	}
+	equipment, err := c.itemRepo.Equipment(equipped...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loadouts := make(map[int][]int, len(m))
+	var ids []int
+	for p, w := range m {
+		if w.Items != nil {
+			loadouts[p] = *w.Items
+		} else {
+			loadouts[p] = equipment[w.Character]
+		}
+		ids = append(ids, loadouts[p]...)
+	}
+
+	items, err := findItems(c.itemRepo, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return loadouts, items, nil
+}
+
+// warriorForm puts a character on the field at a level, given either as
+// {"character": 1, "level": 30, "items": [2, 3]} or as a bare character ID,
+// at level 1. Without a list of items, the character wears its equipment,
+// save in battles as of a time.
+type warriorForm struct {
+	Character int    `json:"character"`
+	Level     int    `json:"level"`
+	Items     *[]int `json:"items"`
+}
+
+func (f *warriorForm) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &f.Character); err == nil {
+		f.Level = 1
+		return nil
+	}
+
+	type form warriorForm
+	v := form{Level: 1}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
 	}
+	*f = warriorForm(v)
 
-	return warriors, nil
+	return nil
 }
 
 type observer struct {
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/farseeingnorthwest/playground/battlefield/v2/examples"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestBattleController_CreateBattle(t *testing.T) {
@@ -31,11 +33,12 @@ func TestBattleController_CreateBattle(t *testing.T) {
 
 			r := new(mockCharacterRepository)
 			sr := new(mockSkillRepository)
-			sr.On("FindEx", []int(nil)).Return([]storage.Skill{
+			sr.On("FindExAsOf", time.Time{}, []int(nil)).Return([]storage.Skill{
 				{
 					SkillMeta: storage.SkillMeta{
-						ID:   1,
-						Name: "Normal Attack",
+						ID:       1,
+						Name:     "Normal Attack",
+						SlotType: storage.SlotNormal,
 					},
 					Reactor: (*storage.Reactor)(examples.Regular[0]),
 				},
@@ -75,7 +78,7 @@ func TestBattleController_CreateBattle(t *testing.T) {
 					Reactor: (*storage.Reactor)(examples.Special[0][3]),
 				},
 			}, nil)
-			r.On("Find", []int{1}).Return([]storage.Character{
+			r.On("FindAsOf", time.Time{}, []int{1}).Return([]storage.Character{
 				{
 					ID:           1,
 					Name:         "Oda",
@@ -87,8 +90,9 @@ func TestBattleController_CreateBattle(t *testing.T) {
 					Speed:        10,
 					Skills: map[int]storage.SkillMeta{
 						0: {
-							ID:   1,
-							Name: "Normal Attack",
+							ID:       1,
+							Name:     "Normal Attack",
+							SlotType: storage.SlotNormal,
 						},
 						1: {
 							ID:   3,
@@ -109,7 +113,7 @@ func TestBattleController_CreateBattle(t *testing.T) {
 					},
 				},
 			}, nil)
-			r.On("Find", []int{2}).Return([]storage.Character{
+			r.On("FindAsOf", time.Time{}, []int{2}).Return([]storage.Character{
 				{
 					ID:           2,
 					Name:         "Ueno",
@@ -121,17 +125,22 @@ func TestBattleController_CreateBattle(t *testing.T) {
 					Speed:        9,
 					Skills: map[int]storage.SkillMeta{
 						0: {
-							ID:   1,
-							Name: "Normal Attack",
+							ID:       1,
+							Name:     "Normal Attack",
+							SlotType: storage.SlotNormal,
 						},
 					},
 				},
 			}, nil)
 
+			ir := new(mockItemRepository)
+			ir.On("Equipment", []int{1}).Return(map[int][]int{}, nil)
+			ir.On("Equipment", []int{2}).Return(map[int][]int{}, nil)
+
 			app := fiber.New()
-			controller.NewBattleController(r, sr).Mount(app)
+			controller.NewBattleController(r, sr, ir, controller.DefaultCharacterRules()).Mount(app)
 			req := httptest.NewRequest("POST", "/battles", strings.NewReader(
-				fmt.Sprintf(`{"left":{"0":1},"right":{"0":2},"ground":[2],"deadline":%v}`, tt.deadline)))
+				fmt.Sprintf(`{"left":{"0":1},"right":{"0":{"character":2,"level":1}},"ground":[2],"deadline":%v}`, tt.deadline)))
 			req.Header.Set("Content-Type", "application/json")
 			resp, err := app.Test(req)
 
@@ -139,6 +148,7 @@ func TestBattleController_CreateBattle(t *testing.T) {
 			sr.AssertExpectations(t)
 			assert.NoError(t, err)
 			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Len(t, resp.Header.Get("X-Battle-Snapshot"), 64)
 
 			body, err := io.ReadAll(resp.Body)
 			assert.NoError(t, err)
@@ -155,3 +165,48 @@ func TestBattleController_CreateBattle(t *testing.T) {
 		})
 	}
 }
+
+func TestBattleController_CreateBattle_Level(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	sr.On("FindExAsOf", time.Time{}, []int(nil)).Return([]storage.Skill(nil), nil)
+
+	app := fiber.New()
+	controller.NewBattleController(r, sr, new(mockItemRepository), controller.DefaultCharacterRules()).Mount(app)
+	req := httptest.NewRequest("POST", "/battles", strings.NewReader(
+		`{"left":{"0":{"character":1,"level":61}},"right":{"0":2}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "FindAsOf")
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "level 61 is out of range [1, 60]", string(body))
+}
+
+func TestBattleController_CreateBattle_AsOfItems(t *testing.T) {
+	asOf := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	ir := new(mockItemRepository)
+	sr.On("FindExAsOf", asOf, []int(nil)).Return([]storage.Skill(nil), nil)
+	r.On("FindAsOf", asOf, []int{1}).Return([]storage.Character{{ID: 1, Name: "Oda"}}, nil)
+
+	app := fiber.New()
+	controller.NewBattleController(r, sr, ir, controller.DefaultCharacterRules()).Mount(app)
+	req := httptest.NewRequest("POST", "/battles", strings.NewReader(
+		`{"left":{"0":{"character":1,"items":[2]}},"right":{"0":2},"as_of":"2026-10-01T00:00:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	ir.AssertNotCalled(t, "Equipment", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "items cannot be worn as of a time", string(body))
+}
@@ -1,28 +1,42 @@
 package controller
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/farseeingnorthwest/battleground.go/functional"
 	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/farseeingnorthwest/playground/battlefield/v2"
 	"github.com/gofiber/fiber/v2"
 )
 
 type CharacterController struct {
 	repo      CharacterRepository
 	skillRepo SkillRepository
+	rules     CharacterRules
+	tagTypes  *tagtype.Registry
 }
 
 type CharacterRepository interface {
 	Find(...int) ([]storage.Character, error)
+	FindAsOf(time.Time, ...int) ([]storage.Character, error)
+	Query(storage.CharacterQuery) ([]storage.Character, string, error)
 	Create(*storage.Character) error
 	Get(int) (*storage.Character, error)
 	Update(*storage.Character) error
-	Delete(int, bool) error
+	Delete(int, int, bool) error
+	Trash() ([]storage.Character, error)
+	Restore(int) (*storage.Character, error)
+	Purge(int) error
 }
 
-func NewCharacterController(repo CharacterRepository, skillRepo SkillRepository) CharacterController {
-	return CharacterController{repo, skillRepo}
+func NewCharacterController(repo CharacterRepository, skillRepo SkillRepository, rules CharacterRules, tagTypes *tagtype.Registry) CharacterController {
+	return CharacterController{repo, skillRepo, rules, tagTypes}
 }
 
 func (c CharacterController) Mount(router fiber.Router) {
@@ -30,16 +44,26 @@ func (c CharacterController) Mount(router fiber.Router) {
 	router.Post("/characters", c.CreateCharacter)
 	router.Get("/characters/:id", c.GetCharacter)
 	router.Put("/characters/:id", c.UpdateCharacter)
+	router.Patch("/characters/:id", c.PatchCharacter)
 	router.Delete("/characters/:id", c.DeleteCharacter)
+	router.Post("/characters/:id/clone", c.CloneCharacter)
+	router.Get("/trash/characters", c.GetTrashedCharacters)
+	router.Post("/trash/characters/:id/restore", c.RestoreCharacter)
+	router.Delete("/trash/characters/:id", c.PurgeCharacter)
 }
 
 func (c CharacterController) GetCharacters(fc *fiber.Ctx) error {
-	characters, err := c.repo.Find()
+	q, err := parseCharacterQuery(fc)
 	if err != nil {
 		return err
 	}
+	characters, next, err := c.repo.Query(q)
+	if err != nil {
+		return storageError(err)
+	}
 
-	return fc.JSON(functional.MapSlice(newCharacterView, characters))
+	setNextCursor(fc, next)
+	return fc.JSON(functional.MapSlice(c.view, characters))
 }
 
 func (c CharacterController) CreateCharacter(fc *fiber.Ctx) error {
@@ -48,10 +72,11 @@ func (c CharacterController) CreateCharacter(fc *fiber.Ctx) error {
 		return err
 	}
 	if err := c.repo.Create(character); err != nil {
-		return err
+		return storageError(err)
 	}
 
-	return fc.JSON((*characterView)(character))
+	setETag(fc, character.Revision)
+	return fc.JSON(c.view(*character))
 }
 
 func (c CharacterController) GetCharacter(fc *fiber.Ctx) error {
@@ -59,25 +84,54 @@ func (c CharacterController) GetCharacter(fc *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	asOf, err := parseAsOf(fc.Query("as_of"))
+	if err != nil {
+		return err
+	}
+	if !asOf.IsZero() {
+		return c.getCharacterAsOf(fc, id, asOf)
+	}
 
 	character, err := c.repo.Get(id)
 	if err != nil {
-		return err
+		return storageError(err)
 	}
 
-	return fc.JSON((*characterView)(character))
+	setETag(fc, character.Revision)
+	return fc.JSON(c.view(*character))
+}
+
+// getCharacterAsOf responds with a past version of the character. It comes
+// without an ETag, since it cannot be updated.
+func (c CharacterController) getCharacterAsOf(fc *fiber.Ctx, id int, asOf time.Time) error {
+	characters, err := c.repo.FindAsOf(asOf, id)
+	if err != nil {
+		return storageError(err)
+	}
+	if len(characters) == 0 {
+		return fiber.ErrNotFound
+	}
+
+	return fc.JSON(c.view(characters[0]))
 }
 
 func (c CharacterController) UpdateCharacter(fc *fiber.Ctx) error {
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
 	character, err := c.form(fc, true)
 	if err != nil {
 		return err
 	}
+
+	character.Revision = revision
 	if err = c.repo.Update(character); err != nil {
-		return err
+		return storageError(err)
 	}
 
-	return fc.JSON((*characterView)(character))
+	setETag(fc, character.Revision)
+	return fc.JSON(c.view(*character))
 }
 
 func (c CharacterController) DeleteCharacter(fc *fiber.Ctx) error {
@@ -85,14 +139,163 @@ func (c CharacterController) DeleteCharacter(fc *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-
-	if err := c.repo.Delete(id, false); err != nil {
+	revision, err := ifMatch(fc)
+	if err != nil {
 		return err
 	}
 
+	if err := c.repo.Delete(id, revision, fc.QueryBool("force")); err != nil {
+		return storageError(err)
+	}
+
 	return fc.SendStatus(fiber.StatusNoContent)
 }
 
+func (c CharacterController) PatchCharacter(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+	patch, err := patchBody(fc)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+	if revision != 0 && revision != current.Revision {
+		return storageError(storage.ErrStale)
+	}
+	doc, err := json.Marshal(newCharacterForm(current))
+	if err != nil {
+		return err
+	}
+	if doc, err = mergePatch(doc, patch); err != nil {
+		return err
+	}
+
+	var form characterForm
+	if err := json.Unmarshal(doc, &form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	character, err := c.character(id, form)
+	if err != nil {
+		return err
+	}
+
+	character.Revision = current.Revision
+	if err := c.repo.Update(character); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, character.Revision)
+	return fc.JSON(c.view(*character))
+}
+
+// CloneCharacter copies the character as stored, so that the clone of a
+// child is a sibling overriding the same fields. With ?inherit=true, the
+// clone is a child of the character instead, overriding nothing but its
+// tags. The clone is named after the body, if any, or else after the
+// character, and is checked as a new character would be.
+func (c CharacterController) CloneCharacter(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	var form struct {
+		Name string `json:"name"`
+	}
+	if len(fc.Body()) > 0 {
+		if err := fc.BodyParser(&form); err != nil {
+			return err
+		}
+	}
+
+	source, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	cloneForm := newCharacterForm(source)
+	if fc.QueryBool("inherit") {
+		cloneForm = characterForm{Name: source.Name, ParentID: &id, Tags: source.Tags}
+	}
+	if form.Name != "" {
+		cloneForm.Name = form.Name
+	}
+	clone, err := c.character(0, cloneForm)
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Create(clone); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, clone.Revision)
+	return fc.JSON(c.view(*clone))
+}
+
+// characterForm describes a character. A child, which has a parent, takes
+// the stats and the layout it leaves out from it, and its growth and skills
+// too if it has none. Other characters have zero stats, no growth and no skills by
+// default.
+type characterForm struct {
+	Name         string             `json:"name"`
+	ParentID     *int               `json:"parent_id"`
+	Damage       *int               `json:"damage"`
+	Defense      *int               `json:"defense"`
+	CriticalOdds *int               `json:"critical_odds"`
+	CriticalLoss *int               `json:"critical_loss"`
+	Health       *int               `json:"health"`
+	Speed        *int               `json:"speed"`
+	Layout       storage.SlotLayout `json:"layout"`
+	Tags         storage.Tags       `json:"tags"`
+	Growth       storage.Growth     `json:"growth"`
+	Skills       map[int]int        `json:"skills"`
+}
+
+// newCharacterForm describes the character as stored, leaving out what it
+// inherits.
+func newCharacterForm(character *storage.Character) characterForm {
+	own := func(stat string, value int) *int {
+		if character.Inherits(stat) {
+			return nil
+		}
+		return &value
+	}
+
+	form := characterForm{
+		Name:         character.Name,
+		ParentID:     character.ParentID,
+		Damage:       own("damage", character.Damage),
+		Defense:      own("defense", character.Defense),
+		CriticalOdds: own("critical_odds", character.CriticalOdds),
+		CriticalLoss: own("critical_loss", character.CriticalLoss),
+		Health:       own("health", character.Health),
+		Speed:        own("speed", character.Speed),
+		Tags:         character.Tags,
+	}
+	if !character.Inherits("layout") {
+		form.Layout = character.Layout
+	}
+	if !character.Inherits("growth") {
+		form.Growth = character.Growth
+	}
+	if !character.Inherits("skills") {
+		form.Skills = functional.MapValues(func(skill storage.SkillMeta) int {
+			return skill.ID
+		}, character.Skills)
+	}
+
+	return form
+}
+
 func (c CharacterController) form(fc *fiber.Ctx, withID bool) (*storage.Character, error) {
 	var id int
 	if withID {
@@ -102,56 +305,133 @@ func (c CharacterController) form(fc *fiber.Ctx, withID bool) (*storage.Characte
 		}
 	}
 
-	form := struct {
-		Name         string
-		Damage       int
-		Defense      int
-		CriticalOdds int `json:"critical_odds"`
-		CriticalLoss int `json:"critical_loss"`
-		Health       int
-		Speed        int
-		Skills       map[int]int
-	}{
-		Skills: make(map[int]int),
-	}
+	var form characterForm
 	if err := fc.BodyParser(&form); err != nil {
 		return nil, err
 	}
-	var skills map[int]storage.SkillMeta
-	if len(form.Skills) > 0 {
+
+	return c.character(id, form)
+}
+
+func (c CharacterController) character(id int, form characterForm) (*storage.Character, error) {
+	var parent storage.Character
+	if form.ParentID != nil {
+		p, err := c.repo.Get(*form.ParentID)
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, sql.ErrNoRows) {
+			return nil, fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("unknown parent %d", *form.ParentID))
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent = *p
+	}
+
+	character := &storage.Character{
+		ID:       id,
+		Name:     form.Name,
+		ParentID: form.ParentID,
+		Layout:   form.Layout,
+		Tags:     form.Tags,
+		Growth:   form.Growth,
+	}
+	for _, f := range []struct {
+		name   string
+		stat   *int
+		value  *int
+		parent int
+	}{
+		{"damage", &character.Damage, form.Damage, parent.Damage},
+		{"defense", &character.Defense, form.Defense, parent.Defense},
+		{"critical_odds", &character.CriticalOdds, form.CriticalOdds, parent.CriticalOdds},
+		{"critical_loss", &character.CriticalLoss, form.CriticalLoss, parent.CriticalLoss},
+		{"health", &character.Health, form.Health, parent.Health},
+		{"speed", &character.Speed, form.Speed, parent.Speed},
+	} {
+		switch {
+		case f.value != nil:
+			*f.stat = *f.value
+		case form.ParentID != nil:
+			*f.stat = f.parent
+			character.Inherited = append(character.Inherited, f.name)
+		}
+	}
+	if form.ParentID != nil && form.Layout == nil {
+		character.Layout = parent.Layout
+		character.Inherited = append(character.Inherited, "layout")
+	}
+	if form.ParentID != nil && len(form.Growth) == 0 {
+		character.Growth = parent.Growth
+		character.Inherited = append(character.Inherited, "growth")
+	}
+	if _, err := warriorTags(character.Tags); err != nil {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid tags: "+err.Error())
+	}
+	tags := functional.MapSlice(func(tag map[string]any) any { return tag }, character.Tags)
+	if problems := c.tagTypes.Check(map[string]any{"tags": tags}); len(problems) > 0 {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "invalid tags: "+strings.Join(problems, "; "))
+	}
+
+	var metas map[int]storage.SkillMeta
+	if form.ParentID != nil && len(form.Skills) == 0 {
+		character.Inherited = append(character.Inherited, "skills")
+		form.Skills = functional.MapValues(func(skill storage.SkillMeta) int {
+			return skill.ID
+		}, parent.Skills)
+		metas = functional.Tabulate[int, storage.SkillMeta](bySkillMetaID(functional.Values(parent.Skills)))
+	} else if len(form.Skills) > 0 {
 		skillMetas, err := c.skillRepo.Find(functional.Values(form.Skills)...)
 		if err != nil {
 			return nil, err
 		}
+		metas = functional.Tabulate[int, storage.SkillMeta](bySkillMetaID(skillMetas))
+	}
+	if err := c.rules.check(character, form.Skills, metas); err != nil {
+		return nil, err
+	}
 
-		metas := functional.Tabulate[int, storage.SkillMeta](bySkillMetaID(skillMetas))
-		skills = make(map[int]storage.SkillMeta)
+	if len(form.Skills) > 0 {
+		character.Skills = make(map[int]storage.SkillMeta)
 		for slot, id := range form.Skills {
-			skills[slot] = metas[id]
+			character.Skills[slot] = metas[id]
 		}
 	}
 
-	return &storage.Character{
-		ID:           id,
-		Name:         form.Name,
-		Damage:       form.Damage,
-		Defense:      form.Defense,
-		CriticalOdds: form.CriticalOdds,
-		CriticalLoss: form.CriticalLoss,
-		Health:       form.Health,
-		Speed:        form.Speed,
-		Skills:       skills,
-	}, nil
+	return character, nil
+}
+
+// warriorTags decodes the tags of a character the way the tags of a reactor
+// are, which rejects tags of unregistered types.
+func warriorTags(tags storage.Tags) ([]any, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(map[string]any{"tags": tags})
+	if err != nil {
+		return nil, err
+	}
+	var f battlefield.FatReactorFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if f.FatReactor == nil {
+		return nil, nil
+	}
+
+	return f.FatReactor.Tags(), nil
 }
 
 type characterView storage.Character
 
-func newCharacterView(character storage.Character) characterView {
+// view shows the character with the default layout if it has none of its
+// own.
+func (c CharacterController) view(character storage.Character) characterView {
+	character.Layout = c.rules.layout(&character)
 	return characterView(character)
 }
 
 func (c characterView) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]any{
+	v := map[string]any{
 		"id":            c.ID,
 		"name":          c.Name,
 		"damage":        c.Damage,
@@ -160,8 +440,21 @@ func (c characterView) MarshalJSON() ([]byte, error) {
 		"critical_loss": c.CriticalLoss,
 		"health":        c.Health,
 		"speed":         c.Speed,
+		"layout":        c.Layout,
+		"tags":          c.Tags,
+		"growth":        c.Growth,
+		"revision":      c.Revision,
 		"skills":        functional.MapValues(newSkillMetaView, c.Skills),
-	})
+	}
+	if c.DeletedAt != nil {
+		v["deleted_at"] = c.DeletedAt
+	}
+	if c.ParentID != nil {
+		v["parent_id"] = c.ParentID
+		v["inherited"] = c.Inherited
+	}
+
+	return json.Marshal(v)
 }
 
 type byCharacterID []storage.Character
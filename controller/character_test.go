@@ -5,9 +5,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/farseeingnorthwest/battleground.go/controller"
 	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -16,7 +18,7 @@ import (
 func TestCharacterController_GetCharacters(t *testing.T) {
 	r := new(mockCharacterRepository)
 	sr := new(mockSkillRepository)
-	r.On("Find", []int(nil)).Return([]storage.Character{
+	r.On("Query", storage.CharacterQuery{Query: storage.Query{Limit: 100}}).Return([]storage.Character{
 		{
 			ID:           1,
 			Name:         "Oda",
@@ -28,15 +30,16 @@ func TestCharacterController_GetCharacters(t *testing.T) {
 			Speed:        10,
 			Skills: map[int]storage.SkillMeta{
 				1: {
-					ID:   1,
-					Name: "Normal Attack",
+					ID:       1,
+					Name:     "Normal Attack",
+					SlotType: storage.SlotNormal,
 				},
 			},
 		},
-	}, nil)
+	}, "", nil)
 
 	app := fiber.New()
-	NewCharacterController(r, sr).Mount(app)
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("GET", "/characters", nil)
 	resp, err := app.Test(req)
 
@@ -49,6 +52,43 @@ func TestCharacterController_GetCharacters(t *testing.T) {
 	assert.Contains(t, string(body), "Normal Attack")
 }
 
+func TestCharacterController_GetCharacters_Query(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Query", storage.CharacterQuery{
+		Query: storage.Query{
+			IDs:   []int{1, 2},
+			Name:  "o",
+			Sort:  []storage.Order{{Key: "speed", Desc: true}, {Key: "name"}},
+			After: "WzEwLDFd",
+			Limit: 1,
+		},
+		Stats: []storage.StatRange{
+			{Stat: "speed", Comparator: ">=", Value: 10},
+			{Stat: "health", Comparator: "<", Value: 200},
+		},
+	}).Return([]storage.Character{
+		{
+			ID:   2,
+			Name: "Ueno",
+		},
+	}, "WzExLDJd", nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/characters?ids=1,2&name=o&sort=-speed,name&after=WzEwLDFd&limit=1&speed>=10&health<200", nil)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "WzExLDJd", resp.Header.Get("X-Next-Cursor"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Ueno")
+}
+
 func TestCharacterController_CreateCharacter(t *testing.T) {
 	r := new(mockCharacterRepository)
 	sr := new(mockSkillRepository)
@@ -62,8 +102,9 @@ func TestCharacterController_CreateCharacter(t *testing.T) {
 		Speed:        9,
 		Skills: map[int]storage.SkillMeta{
 			1: {
-				ID:   2,
-				Name: "Sleep",
+				ID:       2,
+				Name:     "Sleep",
+				SlotType: storage.SlotSpecial,
 			},
 		},
 	}).Run(func(args mock.Arguments) {
@@ -71,13 +112,14 @@ func TestCharacterController_CreateCharacter(t *testing.T) {
 	}).Return(nil)
 	sr.On("Find", []int{2}).Return([]storage.SkillMeta{
 		{
-			ID:   2,
-			Name: "Sleep",
+			ID:       2,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
 		},
 	}, nil)
 
 	app := fiber.New()
-	NewCharacterController(r, sr).Mount(app)
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("POST", "/characters", strings.NewReader(
 		`{"name":"Toy","damage":9,"defense":4,"critical_odds":10,"critical_loss":150,"health":80,"speed":9,"skills":{"1":2}}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -94,6 +136,303 @@ func TestCharacterController_CreateCharacter(t *testing.T) {
 	assert.Contains(t, string(body), "Sleep")
 }
 
+func TestCharacterController_CreateCharacter_Slots(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	sr.On("Find", mock.Anything).Return([]storage.SkillMeta{
+		{
+			ID:       2,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
+		},
+	}, nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	for _, tt := range []struct {
+		body   string
+		errors []string
+	}{
+		{`"skills":{"5":2}`, []string{"slot 5 is out of range [0, 5)"}},
+		{`"skills":{"0":2,"1":7}`, []string{"slot 0 is normal, but skill 2 is special", "slot 1 holds unknown skill 7"}},
+		{`"layout":["special","passive"],"skills":{"2":2}`, []string{"slot 2 is out of range [0, 2)"}},
+		{`"layout":["special","special","special","special","special","special"]`, []string{"layout has 6 slots, more than 5"}},
+		{`"layout":["active"]`, []string{`slot 0 has unknown type "active"`}},
+	} {
+		t.Run(tt.body, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/characters", strings.NewReader(`{"name":"Toy",`+tt.body+`}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Join(tt.errors, "; "), string(body))
+		})
+	}
+	r.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCharacterController_CreateCharacter_Stats(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Create", mock.Anything).Return(nil)
+
+	rules := DefaultCharacterRules()
+	rules.MaxSlots = 5
+	rules.Budget = &StatBudget{
+		Weights: map[string]int{"damage": 4, "defense": 3, "health": 1},
+		Cap:     200,
+	}
+
+	app := fiber.New()
+	NewCharacterController(r, sr, rules, new(tagtype.Registry)).Mount(app)
+	for _, tt := range []struct {
+		body   string
+		status int
+		errors []string
+	}{
+		{`{"damage":9,"defense":4,"critical_odds":10,"critical_loss":150,"health":80,"speed":9}`, fiber.StatusOK, nil},
+		{`{"damage":9,"defense":4,"critical_odds":101,"critical_loss":150,"health":-1,"speed":0}`, fiber.StatusUnprocessableEntity, []string{
+			"critical_odds 101 is above 100",
+			"health -1 is below 1",
+			"speed 0 is below 1",
+		}},
+		{`{"damage":30,"defense":20,"critical_odds":10,"critical_loss":150,"health":100,"speed":9}`, fiber.StatusUnprocessableEntity, []string{
+			"stats cost 280 points, over the budget of 200",
+		}},
+		{`{"damage":9,"defense":4,"critical_odds":10,"critical_loss":150,"health":80,"speed":9,"growth":{"damage":{"per_level":1},"health":{"table":[80,90,100]}}}`, fiber.StatusOK, nil},
+		{`{"damage":9,"defense":4,"critical_odds":10,"critical_loss":150,"health":80,"speed":9,"growth":{"luck":{"per_level":1},"speed":{"per_level":1,"table":[9]}}}`, fiber.StatusUnprocessableEntity, []string{
+			`growth of unknown stat "luck"`,
+			"speed grows both per level and by table",
+		}},
+		{`{"damage":9,"defense":4,"critical_odds":10,"critical_loss":150,"health":80,"speed":9,"growth":{"critical_odds":{"per_level":2}}}`, fiber.StatusUnprocessableEntity, []string{
+			"critical_odds 102 at level 47 is above 100",
+		}},
+	} {
+		t.Run(tt.body, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/characters", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.errors != nil {
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, strings.Join(tt.errors, "; "), string(body))
+			}
+		})
+	}
+}
+
+func TestCharacterController_CreateCharacter_Parent(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	parentID := 1
+	r.On("Get", 1).Return(&storage.Character{
+		ID:           1,
+		Name:         "Oda",
+		Damage:       10,
+		Defense:      5,
+		CriticalOdds: 10,
+		CriticalLoss: 200,
+		Health:       100,
+		Speed:        10,
+		Skills: map[int]storage.SkillMeta{
+			0: {
+				ID:       1,
+				Name:     "Normal Attack",
+				SlotType: storage.SlotNormal,
+			},
+		},
+	}, nil)
+	r.On("Create", &storage.Character{
+		Name:         "Oda Jr.",
+		ParentID:     &parentID,
+		Damage:       12,
+		Defense:      5,
+		CriticalOdds: 10,
+		CriticalLoss: 200,
+		Health:       100,
+		Speed:        10,
+		Inherited:    []string{"defense", "critical_odds", "critical_loss", "health", "speed", "layout", "growth", "skills"},
+		Skills: map[int]storage.SkillMeta{
+			0: {
+				ID:       1,
+				Name:     "Normal Attack",
+				SlotType: storage.SlotNormal,
+			},
+		},
+	}).Run(func(args mock.Arguments) {
+		args.Get(0).(*storage.Character).ID = 2
+	}).Return(nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/characters", strings.NewReader(
+		`{"name":"Oda Jr.","parent_id":1,"damage":12}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	sr.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"parent_id":1`)
+	assert.Contains(t, string(body), "Normal Attack")
+}
+
+func TestCharacterController_CreateCharacter_TagValue(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	tagTypes, err := tagtype.NewRegistry(storage.TagType{Name: "faction", Values: []string{"north", "south"}})
+	assert.NoError(t, err)
+	tagTypes.Register()
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, tagTypes).Mount(app)
+	req := httptest.NewRequest("POST", "/characters", strings.NewReader(
+		`{"name":"Oda","tags":[{"_kind":"faction","value":"east"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `invalid tags: $.tags[0]: faction has no value "east"`, string(body))
+}
+
+func TestCharacterController_CreateCharacter_UnknownParent(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Get", 9).Return((*storage.Character)(nil), storage.ErrNotFound)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/characters", strings.NewReader(
+		`{"name":"Orphan","parent_id":9}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestCharacterController_CloneCharacter(t *testing.T) {
+	original := &storage.Character{
+		ID:           1,
+		Name:         "Oda",
+		Damage:       10,
+		Defense:      5,
+		CriticalOdds: 10,
+		CriticalLoss: 200,
+		Health:       100,
+		Speed:        10,
+		Revision:     3,
+	}
+	parentID := 1
+
+	for _, tt := range []struct {
+		name  string
+		query string
+		body  string
+		clone storage.Character
+	}{
+		{
+			name: "copy",
+			clone: storage.Character{
+				Name:         "Oda",
+				Damage:       10,
+				Defense:      5,
+				CriticalOdds: 10,
+				CriticalLoss: 200,
+				Health:       100,
+				Speed:        10,
+			},
+		},
+		{
+			name:  "inherit",
+			query: "?inherit=true",
+			body:  `{"name":"Oda Jr."}`,
+			clone: storage.Character{
+				Name:         "Oda Jr.",
+				ParentID:     &parentID,
+				Damage:       10,
+				Defense:      5,
+				CriticalOdds: 10,
+				CriticalLoss: 200,
+				Health:       100,
+				Speed:        10,
+				Inherited:    []string{"damage", "defense", "critical_odds", "critical_loss", "health", "speed", "layout", "growth", "skills"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := new(mockCharacterRepository)
+			r.On("Get", 1).Return(original, nil)
+			r.On("Create", &tt.clone).Run(func(args mock.Arguments) {
+				args.Get(0).(*storage.Character).ID = 2
+				args.Get(0).(*storage.Character).Revision = 1
+			}).Return(nil)
+
+			app := fiber.New()
+			NewCharacterController(r, new(mockSkillRepository), CharacterRules{}, new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest("POST", "/characters/1/clone"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			r.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+			assert.Equal(t, 3, original.Revision)
+		})
+	}
+}
+
+func TestCharacterController_CloneCharacter_Rules(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Get", 1).Return(&storage.Character{
+		ID:     1,
+		Name:   "Oda",
+		Health: 100,
+		Skills: map[int]storage.SkillMeta{
+			0: {ID: 1, Name: "Normal Attack", SlotType: storage.SlotNormal},
+		},
+	}, nil)
+	sr.On("Find", []int{1}).Return([]storage.SkillMeta{
+		{ID: 1, Name: "Normal Attack", SlotType: storage.SlotNormal},
+	}, nil)
+
+	app := fiber.New()
+	maxHealth := 50
+	NewCharacterController(r, sr, CharacterRules{
+		MaxSlots: 5,
+		Stats:    map[string]StatRule{"health": {Max: &maxHealth}},
+	}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/characters/1/clone", nil)
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "health 100 is above 50")
+}
+
 func TestCharacterController_GetCharacter(t *testing.T) {
 	r := new(mockCharacterRepository)
 	sr := new(mockSkillRepository)
@@ -106,27 +445,65 @@ func TestCharacterController_GetCharacter(t *testing.T) {
 		CriticalLoss: 200,
 		Health:       100,
 		Speed:        10,
+		Revision:     1,
 	}, nil)
 
 	app := fiber.New()
-	NewCharacterController(r, sr).Mount(app)
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("GET", "/characters/1", nil)
 	resp, err := app.Test(req)
 
 	assert.NoError(t, err)
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
 
 	body, err := io.ReadAll(resp.Body)
 	assert.NoError(t, err)
 	assert.Contains(t, string(body), "Oda")
 }
 
+func TestCharacterController_GetCharacter_AsOf(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("FindAsOf", time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), []int{1}).Return([]storage.Character{
+		{
+			ID:       1,
+			Name:     "Oda",
+			Damage:   8,
+			Revision: 1,
+		},
+	}, nil)
+	r.On("FindAsOf", time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), []int{1}).Return([]storage.Character(nil), nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	for _, tt := range []struct {
+		asOf   string
+		status int
+	}{
+		{"2026-09-01", fiber.StatusOK},
+		{"2026-09-01T00:00:00Z", fiber.StatusOK},
+		{"2026-07-01", fiber.StatusNotFound},
+		{"yesterday", fiber.StatusBadRequest},
+	} {
+		t.Run(tt.asOf, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/characters/1?as_of="+tt.asOf, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Empty(t, resp.Header.Get("ETag"))
+		})
+	}
+}
+
 func TestCharacterController_UpdateCharacter(t *testing.T) {
 	r := new(mockCharacterRepository)
 	sr := new(mockSkillRepository)
 	r.On("Update", &storage.Character{
 		ID:           1,
 		Name:         "Oda",
+		Revision:     1,
 		Damage:       9,
 		Defense:      4,
 		CriticalOdds: 10,
@@ -135,23 +512,26 @@ func TestCharacterController_UpdateCharacter(t *testing.T) {
 		Speed:        9,
 		Skills: map[int]storage.SkillMeta{
 			4: {
-				ID:   2,
-				Name: "Sleep",
+				ID:       2,
+				Name:     "Sleep",
+				SlotType: storage.SlotSpecial,
 			},
 		},
 	}).Return(nil)
 	sr.On("Find", []int{2}).Return([]storage.SkillMeta{
 		{
-			ID:   2,
-			Name: "Sleep",
+			ID:       2,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
 		},
 	}, nil)
 
 	app := fiber.New()
-	NewCharacterController(r, sr).Mount(app)
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("PUT", "/characters/1", strings.NewReader(
 		`{"name":"Oda","damage":9,"defense":4,"critical_odds":10,"critical_loss":150,"health":80,"speed":9,"skills":{"4":2}}`))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
 	resp, err := app.Test(req)
 
 	r.AssertExpectations(t)
@@ -165,14 +545,134 @@ func TestCharacterController_UpdateCharacter(t *testing.T) {
 	assert.Contains(t, string(body), "Sleep")
 }
 
+func TestCharacterController_PatchCharacter(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Get", 1).Return(&storage.Character{
+		ID:           1,
+		Name:         "Oda",
+		Damage:       10,
+		Defense:      5,
+		CriticalOdds: 10,
+		CriticalLoss: 200,
+		Health:       100,
+		Speed:        10,
+		Revision:     3,
+		Skills: map[int]storage.SkillMeta{
+			0: {
+				ID:       1,
+				Name:     "Normal Attack",
+				SlotType: storage.SlotNormal,
+			},
+		},
+	}, nil)
+	r.On("Update", &storage.Character{
+		ID:           1,
+		Name:         "Oda",
+		Damage:       10,
+		Defense:      5,
+		CriticalOdds: 10,
+		CriticalLoss: 200,
+		Health:       120,
+		Speed:        10,
+		Revision:     3,
+		Skills: map[int]storage.SkillMeta{
+			4: {
+				ID:       2,
+				Name:     "Sleep",
+				SlotType: storage.SlotSpecial,
+			},
+		},
+	}).Return(nil)
+	sr.On("Find", []int{2}).Return([]storage.SkillMeta{
+		{
+			ID:       2,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
+		},
+	}, nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("PATCH", "/characters/1", strings.NewReader(
+		`{"health":120,"skills":{"0":null,"4":2}}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `W/"3"`)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	sr.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "Sleep")
+	assert.NotContains(t, string(body), "Normal Attack")
+}
+
+// TestCharacterController_PatchCharacter_Stat patches a stat of a character
+// shaped like the fixtures, which keeps its skills and default layout.
+func TestCharacterController_PatchCharacter_Stat(t *testing.T) {
+	oda := func(health int) *storage.Character {
+		return &storage.Character{
+			ID:           1,
+			Name:         "Oda",
+			Damage:       10,
+			Defense:      5,
+			CriticalOdds: 10,
+			CriticalLoss: 200,
+			Health:       health,
+			Speed:        10,
+			Revision:     1,
+			Skills: map[int]storage.SkillMeta{
+				0: {
+					ID:       1,
+					Name:     "Normal Attack",
+					SlotType: storage.SlotNormal,
+				},
+			},
+		}
+	}
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Get", 1).Return(oda(100), nil)
+	r.On("Update", oda(120)).Return(nil)
+	sr.On("Find", []int{1}).Return([]storage.SkillMeta{
+		{
+			ID:       1,
+			Name:     "Normal Attack",
+			SlotType: storage.SlotNormal,
+		},
+	}, nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("PATCH", "/characters/1", strings.NewReader(`{"health":120}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	sr.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"layout":["normal","special","special","special","special"]`)
+	assert.Contains(t, string(body), "Normal Attack")
+}
+
 func TestCharacterController_DeleteCharacter(t *testing.T) {
 	r := new(mockCharacterRepository)
 	sr := new(mockSkillRepository)
-	r.On("Delete", 1).Return(nil)
+	r.On("Delete", 1, 2, false).Return(nil)
 
 	app := fiber.New()
-	NewCharacterController(r, sr).Mount(app)
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("DELETE", "/characters/1", nil)
+	req.Header.Set("If-Match", `"2"`)
 	resp, err := app.Test(req)
 
 	r.AssertExpectations(t)
@@ -180,6 +680,42 @@ func TestCharacterController_DeleteCharacter(t *testing.T) {
 	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
 }
 
+func TestCharacterController_Preconditions(t *testing.T) {
+	for _, tt := range []struct {
+		method  string
+		ifMatch string
+		status  int
+	}{
+		{"PUT", "", fiber.StatusPreconditionRequired},
+		{"PATCH", "", fiber.StatusPreconditionRequired},
+		{"DELETE", "", fiber.StatusPreconditionRequired},
+		{"DELETE", "abc", fiber.StatusBadRequest},
+		{"PUT", `"1"`, fiber.StatusPreconditionFailed},
+		{"PATCH", `"1"`, fiber.StatusPreconditionFailed},
+		{"DELETE", `"1"`, fiber.StatusPreconditionFailed},
+	} {
+		t.Run(tt.method+tt.ifMatch, func(t *testing.T) {
+			r := new(mockCharacterRepository)
+			sr := new(mockSkillRepository)
+			r.On("Get", 1).Return(&storage.Character{ID: 1, Name: "Oda", Revision: 2}, nil)
+			r.On("Update", mock.Anything).Return(storage.ErrStale)
+			r.On("Delete", 1, 1, false).Return(storage.ErrStale)
+
+			app := fiber.New()
+			NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest(tt.method, "/characters/1", strings.NewReader(`{"name":"Oda"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
 type mockCharacterRepository struct {
 	mock.Mock
 }
@@ -189,6 +725,16 @@ func (r *mockCharacterRepository) Find(ids ...int) ([]storage.Character, error)
 	return args.Get(0).([]storage.Character), args.Error(1)
 }
 
+func (r *mockCharacterRepository) FindAsOf(asOf time.Time, ids ...int) ([]storage.Character, error) {
+	args := r.Called(asOf, ids)
+	return args.Get(0).([]storage.Character), args.Error(1)
+}
+
+func (r *mockCharacterRepository) Query(q storage.CharacterQuery) ([]storage.Character, string, error) {
+	args := r.Called(q)
+	return args.Get(0).([]storage.Character), args.String(1), args.Error(2)
+}
+
 func (r *mockCharacterRepository) Create(character *storage.Character) error {
 	args := r.Called(character)
 	return args.Error(0)
@@ -204,7 +750,22 @@ func (r *mockCharacterRepository) Update(character *storage.Character) error {
 	return args.Error(0)
 }
 
-func (r *mockCharacterRepository) Delete(id int, force bool) error {
+func (r *mockCharacterRepository) Delete(id, revision int, force bool) error {
+	args := r.Called(id, revision, force)
+	return args.Error(0)
+}
+
+func (r *mockCharacterRepository) Trash() ([]storage.Character, error) {
+	args := r.Called()
+	return args.Get(0).([]storage.Character), args.Error(1)
+}
+
+func (r *mockCharacterRepository) Restore(id int) (*storage.Character, error) {
+	args := r.Called(id)
+	return args.Get(0).(*storage.Character), args.Error(1)
+}
+
+func (r *mockCharacterRepository) Purge(id int) error {
 	args := r.Called(id)
 	return args.Error(0)
 }
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// storageError maps the sentinel errors of the storage layer onto HTTP
+// statuses. Anything else is left as is and ends up as a 500.
+func storageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrInvalidQuery):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrStale):
+		return fiber.NewError(fiber.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, storage.ErrInUse):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, storage.ErrCycle), errors.Is(err, storage.ErrTemplate):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ItemController struct {
+	repo      ItemRepository
+	skillRepo SkillRepository
+}
+
+type ItemRepository interface {
+	Find(ids ...int) ([]storage.Item, error)
+	Get(id int) (*storage.Item, error)
+	Create(item *storage.Item) error
+	Update(item *storage.Item) error
+	Delete(id, revision int, force bool) error
+	Equipment(characterIDs ...int) (map[int][]int, error)
+	Equip(characterID int, itemIDs []int) error
+}
+
+func NewItemController(repo ItemRepository, skillRepo SkillRepository) ItemController {
+	return ItemController{repo, skillRepo}
+}
+
+func (c ItemController) Mount(router fiber.Router) {
+	router.Get("/items", c.GetItems)
+	router.Post("/items", c.CreateItem)
+	router.Get("/items/:id", c.GetItem)
+	router.Put("/items/:id", c.UpdateItem)
+	router.Delete("/items/:id", c.DeleteItem)
+	router.Get("/characters/:id/items", c.GetEquipment)
+	router.Put("/characters/:id/items", c.UpdateEquipment)
+}
+
+func (c ItemController) GetItems(fc *fiber.Ctx) error {
+	items, err := c.repo.Find()
+	if err != nil {
+		return err
+	}
+
+	return fc.JSON(functional.MapSlice(newItemView, items))
+}
+
+func (c ItemController) CreateItem(fc *fiber.Ctx) error {
+	item, err := c.form(fc, 0)
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Create(item); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, item.Revision)
+	return fc.JSON(itemView(*item))
+}
+
+func (c ItemController) GetItem(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	item, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, item.Revision)
+	return fc.JSON(itemView(*item))
+}
+
+func (c ItemController) UpdateItem(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+
+	item, err := c.form(fc, id)
+	if err != nil {
+		return err
+	}
+	item.Revision = revision
+	if err := c.repo.Update(item); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, item.Revision)
+	return fc.JSON(itemView(*item))
+}
+
+func (c ItemController) DeleteItem(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Delete(id, revision, fc.QueryBool("force")); err != nil {
+		return storageError(err)
+	}
+
+	return fc.SendStatus(fiber.StatusNoContent)
+}
+
+// GetEquipment lists the items the character wears into battles which do
+// not give it a loadout.
+func (c ItemController) GetEquipment(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	equipment, err := c.repo.Equipment(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	return c.sendEquipment(fc, equipment[id])
+}
+
+// UpdateEquipment replaces the items of the character with the ones listed
+// by ID in the body.
+func (c ItemController) UpdateEquipment(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	if err := fc.BodyParser(&ids); err != nil {
+		return err
+	}
+	if _, err := findItems(c.repo, ids); err != nil {
+		return err
+	}
+	if err := c.repo.Equip(id, ids); err != nil {
+		return storageError(err)
+	}
+
+	return c.sendEquipment(fc, ids)
+}
+
+func (c ItemController) sendEquipment(fc *fiber.Ctx, ids []int) error {
+	if len(ids) == 0 {
+		return fc.JSON([]itemView{})
+	}
+
+	items, err := c.repo.Find(ids...)
+	if err != nil {
+		return err
+	}
+
+	return fc.JSON(functional.MapSlice(newItemView, items))
+}
+
+type itemForm struct {
+	Name    string          `json:"name"`
+	Bonuses storage.Bonuses `json:"bonuses"`
+	Skills  []int           `json:"skills"`
+}
+
+// form builds the item described by the request body, rejecting bonuses
+// to unknown stats and unknown skills.
+func (c ItemController) form(fc *fiber.Ctx, id int) (*storage.Item, error) {
+	var form itemForm
+	if err := fc.BodyParser(&form); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	stats := functional.Keys(form.Bonuses)
+	sort.Strings(stats)
+	for _, stat := range stats {
+		if !isStat(stat) {
+			problems = append(problems, fmt.Sprintf("bonus to unknown stat %q", stat))
+		}
+	}
+
+	var skills []storage.SkillMeta
+	if len(form.Skills) > 0 {
+		metas, err := c.skillRepo.Find(form.Skills...)
+		if err != nil {
+			return nil, err
+		}
+		known := functional.Tabulate[int, storage.SkillMeta](bySkillMetaID(metas))
+		for _, id := range form.Skills {
+			if skill, ok := known[id]; ok {
+				skills = append(skills, skill)
+			} else {
+				problems = append(problems, fmt.Sprintf("unknown skill %d", id))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, strings.Join(problems, "; "))
+	}
+
+	return &storage.Item{
+		ID:      id,
+		Name:    form.Name,
+		Bonuses: form.Bonuses,
+		Skills:  skills,
+	}, nil
+}
+
+// findItems loads the items by ID, all of which must exist.
+func findItems(repo ItemRepository, ids []int) (map[int]storage.Item, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	items, err := repo.Find(ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := functional.Tabulate[int, storage.Item](byItemID(items))
+	for _, id := range ids {
+		if _, ok := byID[id]; !ok {
+			return nil, fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("unknown item %d", id))
+		}
+	}
+
+	return byID, nil
+}
+
+type itemView storage.Item
+
+func newItemView(item storage.Item) itemView {
+	return itemView(item)
+}
+
+func (v itemView) MarshalJSON() ([]byte, error) {
+	bonuses := v.Bonuses
+	if bonuses == nil {
+		bonuses = storage.Bonuses{}
+	}
+
+	return json.Marshal(map[string]any{
+		"id":       v.ID,
+		"name":     v.Name,
+		"bonuses":  bonuses,
+		"skills":   functional.MapSlice(newSkillMetaView, v.Skills),
+		"revision": v.Revision,
+	})
+}
+
+type byItemID []storage.Item
+
+func (s byItemID) Len() int                      { return len(s) }
+func (s byItemID) Get(i int) (int, storage.Item) { return s[i].ID, s[i] }
@@ -0,0 +1,152 @@
+package controller_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestItemController_CreateItem(t *testing.T) {
+	r := new(mockItemRepository)
+	sr := new(mockSkillRepository)
+	r.On("Create", &storage.Item{
+		Name:    "Sword",
+		Bonuses: storage.Bonuses{"damage": {Flat: 5, Percent: 10}},
+		Skills: []storage.SkillMeta{
+			{
+				ID:       2,
+				Name:     "Sleep",
+				SlotType: storage.SlotSpecial,
+			},
+		},
+	}).Run(func(args mock.Arguments) {
+		item := args.Get(0).(*storage.Item)
+		item.ID = 1
+		item.Revision = 1
+	}).Return(nil)
+	sr.On("Find", []int{2}).Return([]storage.SkillMeta{
+		{
+			ID:       2,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
+		},
+	}, nil)
+
+	app := fiber.New()
+	NewItemController(r, sr).Mount(app)
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(
+		`{"name":"Sword","bonuses":{"damage":{"flat":5,"percent":10}},"skills":[2]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	sr.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": 1,
+		"name": "Sword",
+		"bonuses": {"damage": {"flat": 5, "percent": 10}},
+		"skills": [{"id": 2, "name": "Sleep", "slot_type": "special"}],
+		"revision": 1
+	}`, string(body))
+}
+
+func TestItemController_CreateItem_Invalid(t *testing.T) {
+	r := new(mockItemRepository)
+	sr := new(mockSkillRepository)
+	sr.On("Find", []int{7}).Return([]storage.SkillMeta(nil), nil)
+
+	app := fiber.New()
+	NewItemController(r, sr).Mount(app)
+	req := httptest.NewRequest("POST", "/items", strings.NewReader(
+		`{"name":"Charm","bonuses":{"luck":{"flat":1}},"skills":[7]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `bonus to unknown stat "luck"; unknown skill 7`, string(body))
+}
+
+func TestItemController_UpdateEquipment(t *testing.T) {
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`[1]`, fiber.StatusOK},
+		{`[1,9]`, fiber.StatusUnprocessableEntity},
+	} {
+		t.Run(tt.body, func(t *testing.T) {
+			r := new(mockItemRepository)
+			r.On("Find", mock.Anything).Return([]storage.Item{{ID: 1, Name: "Sword", Revision: 1}}, nil)
+			r.On("Equip", 1, []int{1}).Return(nil)
+
+			app := fiber.New()
+			NewItemController(r, new(mockSkillRepository)).Mount(app)
+			req := httptest.NewRequest("PUT", "/characters/1/items", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status != fiber.StatusOK {
+				r.AssertNotCalled(t, "Equip", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+type mockItemRepository struct {
+	mock.Mock
+}
+
+func (r *mockItemRepository) Find(ids ...int) ([]storage.Item, error) {
+	args := r.Called(ids)
+	return args.Get(0).([]storage.Item), args.Error(1)
+}
+
+func (r *mockItemRepository) Get(id int) (*storage.Item, error) {
+	args := r.Called(id)
+	return args.Get(0).(*storage.Item), args.Error(1)
+}
+
+func (r *mockItemRepository) Create(item *storage.Item) error {
+	args := r.Called(item)
+	return args.Error(0)
+}
+
+func (r *mockItemRepository) Update(item *storage.Item) error {
+	args := r.Called(item)
+	return args.Error(0)
+}
+
+func (r *mockItemRepository) Delete(id, revision int, force bool) error {
+	args := r.Called(id, revision, force)
+	return args.Error(0)
+}
+
+func (r *mockItemRepository) Equipment(characterIDs ...int) (map[int][]int, error) {
+	args := r.Called(characterIDs)
+	return args.Get(0).(map[int][]int), args.Error(1)
+}
+
+func (r *mockItemRepository) Equip(characterID int, itemIDs []int) error {
+	args := r.Called(characterID, itemIDs)
+	return args.Error(0)
+}
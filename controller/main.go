@@ -23,6 +23,26 @@ var Module = fx.Module(
 			fx.As(new(Controller)),
 			fx.ResultTags(`group:"controllers"`),
 		),
+		fx.Annotate(
+			NewSkillTemplateController,
+			fx.As(new(Controller)),
+			fx.ResultTags(`group:"controllers"`),
+		),
+		fx.Annotate(
+			NewItemController,
+			fx.As(new(Controller)),
+			fx.ResultTags(`group:"controllers"`),
+		),
+		fx.Annotate(
+			NewSchemaController,
+			fx.As(new(Controller)),
+			fx.ResultTags(`group:"controllers"`),
+		),
+		fx.Annotate(
+			NewTagTypeController,
+			fx.As(new(Controller)),
+			fx.ResultTags(`group:"controllers"`),
+		),
 	),
 )
 
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// mimeSkillNotation is the media type of skills written in the skill
+// notation, where name and slot_type are members beside those of the
+// reactor.
+const mimeSkillNotation = "text/x-skill"
+
+// bindSkill reads the body of a skill request into the form, either in the
+// skill notation or as the body parser of fiber does.
+func bindSkill(fc *fiber.Ctx, form any) error {
+	if !strings.HasPrefix(strings.ToLower(fc.Get(fiber.HeaderContentType)), mimeSkillNotation) {
+		return fc.BodyParser(form)
+	}
+
+	b, err := skillBody(fc)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// skillBody is the body of a skill request as JSON, converted from the skill
+// notation if it is written in it. Syntax errors are reported with their
+// lines and columns.
+func skillBody(fc *fiber.Ctx) ([]byte, error) {
+	if !strings.HasPrefix(strings.ToLower(fc.Get(fiber.HeaderContentType)), mimeSkillNotation) {
+		return fc.Body(), nil
+	}
+
+	doc, err := reactor.Parse(fc.Body())
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	m := doc.(map[string]any)
+	form := make(map[string]any)
+	for _, k := range []string{"name", "slot_type"} {
+		if v, ok := m[k]; ok {
+			form[k] = v
+			delete(m, k)
+		}
+	}
+	if len(m) > 0 {
+		form["reactor"] = m
+	}
+
+	return json.Marshal(form)
+}
+
+// skillPatchBody reads the merge patch of a PATCH request on a skill, which
+// may also be written in the skill notation. The reactor written in the
+// notation is meant to replace the current one as a whole rather than be
+// merged into it, which replace tells; name and slot_type are merged as
+// usual, and the reactor is kept if the notation has no members of it.
+func skillPatchBody(fc *fiber.Ctx) (patch []byte, replace bool, err error) {
+	if !strings.HasPrefix(strings.ToLower(fc.Get(fiber.HeaderContentType)), mimeSkillNotation) {
+		patch, err = patchBody(fc)
+		return patch, false, err
+	}
+
+	if patch, err = skillBody(fc); err != nil {
+		return nil, false, err
+	}
+	var form map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &form); err != nil {
+		return nil, false, err
+	}
+	_, replace = form["reactor"]
+
+	return patch, replace, nil
+}
+
+// sendSkillNotation writes the skill in the skill notation: its name and
+// slot type, then the members of its reactor.
+func sendSkillNotation(fc *fiber.Ctx, skill *storage.Skill) error {
+	doc, err := skill.Reactor.Document()
+	if err != nil {
+		return err
+	}
+
+	b := reactor.Format(map[string]any{
+		"name":      skill.Name,
+		"slot_type": string(skill.SlotType),
+	})
+	if m, ok := doc.(map[string]any); ok && len(m) > 0 {
+		b = append(b, reactor.Format(m)...)
+	}
+
+	fc.Set(fiber.HeaderContentType, mimeSkillNotation+"; charset=utf-8")
+	return fc.Send(b)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const mimeMergePatchJSON = "application/merge-patch+json"
+
+// mergePatch applies an RFC 7396 JSON merge patch to the document: objects
+// are merged recursively, nulls delete members and anything else, arrays
+// included, replaces the target wholesale.
+func mergePatch(doc, patch []byte) ([]byte, error) {
+	var d, p any
+	if err := decodeJSON(doc, &d); err != nil {
+		return nil, err
+	}
+	if err := decodeJSON(patch, &p); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid merge patch: "+err.Error())
+	}
+
+	return json.Marshal(merge(d, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = merge(t[k], v)
+		}
+	}
+
+	return t
+}
+
+func decodeJSON(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// patchBody reads the merge patch of a PATCH request, which may also be sent
+// as plain JSON.
+func patchBody(fc *fiber.Ctx) ([]byte, error) {
+	ct := strings.ToLower(fc.Get(fiber.HeaderContentType))
+	if !strings.HasPrefix(ct, mimeMergePatchJSON) && !strings.HasPrefix(ct, fiber.MIMEApplicationJSON) {
+		return nil, fiber.ErrUnsupportedMediaType
+	}
+
+	return fc.Body(), nil
+}
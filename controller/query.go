@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+var (
+	statRangePattern     = regexp.MustCompile(`^(damage|defense|critical_odds|critical_loss|health|speed)(>=|<=|!=|>|<|=)(-?\d+)$`)
+	priorityRangePattern = regexp.MustCompile(`^priority(>=|<=|!=|>|<|=)(-?\d+)$`)
+)
+
+// parseQuery reads ?ids=1,2&name=oda&sort=-speed,name&after=<cursor>&limit=20
+// and ?as_of=2026-09-01.
+func parseQuery(fc *fiber.Ctx) (storage.Query, error) {
+	q := storage.Query{
+		Name:  fc.Query("name"),
+		After: fc.Query("after"),
+		Limit: defaultPageSize,
+	}
+
+	var err error
+	if q.AsOf, err = parseAsOf(fc.Query("as_of")); err != nil {
+		return q, err
+	}
+
+	if ids := fc.Query("ids"); ids != "" {
+		for _, s := range strings.Split(ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return q, fiber.NewError(fiber.StatusBadRequest, "invalid ids: "+ids)
+			}
+			q.IDs = append(q.IDs, id)
+		}
+	}
+	if sort := fc.Query("sort"); sort != "" {
+		for _, key := range strings.Split(sort, ",") {
+			key = strings.TrimSpace(key)
+			desc := strings.HasPrefix(key, "-")
+			q.Sort = append(q.Sort, storage.Order{
+				Key:  strings.TrimPrefix(key, "-"),
+				Desc: desc,
+			})
+		}
+	}
+	if limit := fc.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return q, fiber.NewError(fiber.StatusBadRequest, "invalid limit: "+limit)
+		}
+		q.Limit = min(n, maxPageSize)
+	}
+
+	return q, nil
+}
+
+// parseCharacterQuery also reads stat ranges such as ?speed>=10&health<50.
+// They are not key-value pairs, so they are recovered from the raw arguments.
+func parseCharacterQuery(fc *fiber.Ctx) (storage.CharacterQuery, error) {
+	q, err := parseQuery(fc)
+	if err != nil {
+		return storage.CharacterQuery{}, err
+	}
+
+	cq := storage.CharacterQuery{Query: q}
+	fc.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		arg := string(key)
+		if len(value) > 0 {
+			arg += "=" + string(value)
+		}
+
+		m := statRangePattern.FindStringSubmatch(arg)
+		if m == nil {
+			return
+		}
+		v, _ := strconv.Atoi(m[3])
+		cq.Stats = append(cq.Stats, storage.StatRange{
+			Stat:       m[1],
+			Comparator: m[2],
+			Value:      v,
+		})
+	})
+
+	return cq, nil
+}
+
+// parseSkillQuery also reads ?label=, ?exclusion_group=, lists of names in
+// ?verb= and ?signal=, and priority ranges such as ?priority>=5, recovered
+// from the raw arguments as stat ranges are.
+func parseSkillQuery(fc *fiber.Ctx) (storage.SkillQuery, error) {
+	q, err := parseQuery(fc)
+	if err != nil {
+		return storage.SkillQuery{}, err
+	}
+
+	sq := storage.SkillQuery{
+		Query:   q,
+		Label:   fc.Query("label"),
+		Verbs:   splitList(fc.Query("verb")),
+		Signals: splitList(fc.Query("signal")),
+	}
+	if group := fc.Query("exclusion_group"); group != "" {
+		n, err := strconv.Atoi(group)
+		if err != nil {
+			return sq, fiber.NewError(fiber.StatusBadRequest, "invalid exclusion_group: "+group)
+		}
+		sq.ExclusionGroup = &n
+	}
+	fc.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		arg := string(key)
+		if len(value) > 0 {
+			arg += "=" + string(value)
+		}
+
+		m := priorityRangePattern.FindStringSubmatch(arg)
+		if m == nil {
+			return
+		}
+		v, _ := strconv.Atoi(m[2])
+		sq.Priority = append(sq.Priority, storage.IndexRange{
+			Comparator: m[1],
+			Value:      v,
+		})
+	})
+
+	return sq, nil
+}
+
+// splitList splits a comma separated list, the empty string into none.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+
+	return items
+}
+
+// parseAsOf accepts an RFC 3339 timestamp or a date, which stands for its
+// midnight in UTC. The empty string yields the zero time, that is now.
+func parseAsOf(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "invalid as_of: "+s)
+}
+
+func setNextCursor(fc *fiber.Ctx, next string) {
+	if next != "" {
+		fc.Set("X-Next-Cursor", next)
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setETag(fc *fiber.Ctx, revision int) {
+	fc.Set(fiber.HeaderETag, strconv.Quote(strconv.Itoa(revision)))
+}
+
+// ifMatch reads the revision a write is conditioned on. The wildcard matches
+// any revision and yields zero, which the repositories take as no condition.
+func ifMatch(fc *fiber.Ctx) (int, error) {
+	tag := strings.TrimSpace(fc.Get(fiber.HeaderIfMatch))
+	if tag == "" {
+		return 0, fiber.NewError(fiber.StatusPreconditionRequired, "If-Match is required")
+	}
+	if tag == "*" {
+		return 0, nil
+	}
+
+	revision, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(tag, "W/"), `"`))
+	if err != nil || revision <= 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "invalid If-Match: "+tag)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// stats are the stat columns of characters, in the order problems with them
+// are reported.
+var stats = []string{"damage", "defense", "critical_odds", "critical_loss", "health", "speed"}
+
+// CharacterRules constrain the characters accepted by the API. The zero
+// value only allows no skill slot and no level but 1, and checks no stat.
+type CharacterRules struct {
+	// MaxSlots is the largest number of skill slots of a character, numbered
+	// from 0.
+	MaxSlots int `yaml:"-"`
+	// MaxLevel is the highest level characters grow to, from 1.
+	MaxLevel int `yaml:"max_level"`
+	// Stats bound the stats by name.
+	Stats map[string]StatRule `yaml:"stats"`
+	// Budget, if any, caps the weighted total of the stats.
+	Budget *StatBudget `yaml:"budget"`
+}
+
+// StatRule bounds a stat on either side, both inclusive.
+type StatRule struct {
+	Min *int `yaml:"min"`
+	Max *int `yaml:"max"`
+}
+
+// StatBudget is a point-buy ruleset: every point of a stat costs its weight,
+// and the total cost may not go over the cap. Stats without a weight are
+// free.
+type StatBudget struct {
+	Weights map[string]int `yaml:"weights"`
+	Cap     int            `yaml:"cap"`
+}
+
+// DefaultCharacterRules keep stats within sensible bounds up to level 60,
+// with no budget.
+func DefaultCharacterRules() CharacterRules {
+	bound := func(min, max *int) StatRule {
+		return StatRule{Min: min, Max: max}
+	}
+	n := func(n int) *int {
+		return &n
+	}
+
+	return CharacterRules{
+		MaxLevel: 60,
+		Stats: map[string]StatRule{
+			"damage":        bound(n(0), nil),
+			"defense":       bound(n(0), nil),
+			"critical_odds": bound(n(0), n(100)),
+			"critical_loss": bound(n(100), nil),
+			"health":        bound(n(1), nil),
+			"speed":         bound(n(1), nil),
+		},
+	}
+}
+
+// LoadCharacterRules reads rules from a YAML document such as
+//
+//	max_level: 80
+//	stats:
+//	  health: {min: 1, max: 500}
+//	budget:
+//	  weights: {damage: 4, defense: 3, health: 1}
+//	  cap: 400
+//
+// Stats missing from it keep their default rule.
+func LoadCharacterRules(path string) (CharacterRules, error) {
+	rules := DefaultCharacterRules()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	var file CharacterRules
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return rules, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if file.MaxLevel != 0 {
+		rules.MaxLevel = file.MaxLevel
+	}
+	for stat, rule := range file.Stats {
+		if !isStat(stat) {
+			return rules, fmt.Errorf("%s: unknown stat %q", path, stat)
+		}
+		rules.Stats[stat] = rule
+	}
+	if file.Budget != nil {
+		for stat := range file.Budget.Weights {
+			if !isStat(stat) {
+				return rules, fmt.Errorf("%s: unknown stat %q", path, stat)
+			}
+		}
+		rules.Budget = file.Budget
+	}
+
+	return rules, nil
+}
+
+func isStat(name string) bool {
+	for _, stat := range stats {
+		if stat == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// layout is the layout of the character, or else the default one.
+func (r CharacterRules) layout(character *storage.Character) storage.SlotLayout {
+	if character.Layout != nil {
+		return character.Layout
+	}
+
+	return r.defaultLayout()
+}
+
+// defaultLayout is the layout of characters not given one: a normal slot
+// followed by special ones, up to the number of slots.
+func (r CharacterRules) defaultLayout() storage.SlotLayout {
+	layout := make(storage.SlotLayout, r.MaxSlots)
+	for i := range layout {
+		layout[i] = storage.SlotSpecial
+	}
+	if len(layout) > 0 {
+		layout[0] = storage.SlotNormal
+	}
+
+	return layout
+}
+
+// checkLevel rejects levels characters do not reach.
+func (r CharacterRules) checkLevel(level int) error {
+	if level < 1 || level > max(r.MaxLevel, 1) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("level %d is out of range [1, %d]", level, max(r.MaxLevel, 1)))
+	}
+
+	return nil
+}
+
+// check rejects the character with every problem found in its stats, growth
+// and slots.
+func (r CharacterRules) check(character *storage.Character, skills map[int]int, known map[int]storage.SkillMeta) error {
+	problems := r.checkStats(character)
+	problems = append(problems, r.checkGrowth(character)...)
+	problems = append(problems, r.checkSlots(r.layout(character), skills, known)...)
+	if len(problems) > 0 {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func statValues(character storage.Character) map[string]int {
+	return map[string]int{
+		"damage":        character.Damage,
+		"defense":       character.Defense,
+		"critical_odds": character.CriticalOdds,
+		"critical_loss": character.CriticalLoss,
+		"health":        character.Health,
+		"speed":         character.Speed,
+	}
+}
+
+func (r CharacterRules) checkStats(character *storage.Character) []string {
+	values := statValues(*character)
+
+	var problems []string
+	for _, stat := range stats {
+		rule, v := r.Stats[stat], values[stat]
+		if rule.Min != nil && v < *rule.Min {
+			problems = append(problems, fmt.Sprintf("%s %d is below %d", stat, v, *rule.Min))
+		}
+		if rule.Max != nil && v > *rule.Max {
+			problems = append(problems, fmt.Sprintf("%s %d is above %d", stat, v, *rule.Max))
+		}
+	}
+	if r.Budget != nil {
+		var cost int
+		for stat, weight := range r.Budget.Weights {
+			cost += weight * values[stat]
+		}
+		if cost > r.Budget.Cap {
+			problems = append(problems, fmt.Sprintf("stats cost %d points, over the budget of %d", cost, r.Budget.Cap))
+		}
+	}
+
+	return problems
+}
+
+// checkGrowth finds growth of unknown stats, tables longer than the levels,
+// and the first level at which a growing stat leaves its bounds. The budget
+// only applies to level 1.
+func (r CharacterRules) checkGrowth(character *storage.Character) []string {
+	var problems []string
+	names := functional.Keys(character.Growth)
+	sort.Strings(names)
+	for _, name := range names {
+		g := character.Growth[name]
+		switch {
+		case !isStat(name):
+			problems = append(problems, fmt.Sprintf("growth of unknown stat %q", name))
+		case g.PerLevel != 0 && len(g.Table) > 0:
+			problems = append(problems, fmt.Sprintf("%s grows both per level and by table", name))
+		case len(g.Table) > max(r.MaxLevel, 1):
+			problems = append(problems, fmt.Sprintf("%s table has %d levels, more than %d", name, len(g.Table), max(r.MaxLevel, 1)))
+		}
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	for _, stat := range stats {
+		if _, ok := character.Growth[stat]; !ok {
+			continue
+		}
+
+		// A table overrides the stat at level 1 too.
+		from := 2
+		if len(character.Growth[stat].Table) > 0 {
+			from = 1
+		}
+
+		rule := r.Stats[stat]
+		for level := from; level <= r.MaxLevel; level++ {
+			v := statValues(character.AtLevel(level))[stat]
+			if rule.Min != nil && v < *rule.Min {
+				problems = append(problems, fmt.Sprintf("%s %d at level %d is below %d", stat, v, level, *rule.Min))
+				break
+			}
+			if rule.Max != nil && v > *rule.Max {
+				problems = append(problems, fmt.Sprintf("%s %d at level %d is above %d", stat, v, level, *rule.Max))
+				break
+			}
+		}
+	}
+
+	return problems
+}
+
+// checkSlots finds invalid layouts, as well as slots out of the layout,
+// holding unknown skills, or skills which do not fit the type of the slot.
+func (r CharacterRules) checkSlots(layout storage.SlotLayout, skills map[int]int, known map[int]storage.SkillMeta) []string {
+	var problems []string
+	if len(layout) > r.MaxSlots {
+		problems = append(problems, fmt.Sprintf("layout has %d slots, more than %d", len(layout), r.MaxSlots))
+	}
+	for slot, slotType := range layout {
+		if !slotType.Valid() {
+			problems = append(problems, fmt.Sprintf("slot %d has unknown type %q", slot, slotType))
+		}
+	}
+
+	slots := functional.Keys(skills)
+	sort.Ints(slots)
+	for _, slot := range slots {
+		skill, ok := known[skills[slot]]
+		switch {
+		case slot < 0 || slot >= len(layout):
+			problems = append(problems, fmt.Sprintf("slot %d is out of range [0, %d)", slot, len(layout)))
+		case !ok:
+			problems = append(problems, fmt.Sprintf("slot %d holds unknown skill %d", slot, skills[slot]))
+		case skill.SlotType != layout[slot]:
+			problems = append(problems, fmt.Sprintf("slot %d is %s, but skill %d is %s", slot, layout[slot], skill.ID, skill.SlotType))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,39 @@
+package controller_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCharacterRules(t *testing.T) {
+	for _, tt := range []struct {
+		content string
+		ok      bool
+	}{
+		{"max_level: 80\nstats:\n  health: {min: 10, max: 500}\nbudget:\n  weights: {damage: 4, health: 1}\n  cap: 400\n", true},
+		{"stats:\n  luck: {min: 0}\n", false},
+		{"budget:\n  weights: {luck: 1}\n", false},
+	} {
+		t.Run("", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.yml")
+			assert.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			rules, err := LoadCharacterRules(path)
+			if !tt.ok {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, 80, rules.MaxLevel)
+			assert.Equal(t, 10, *rules.Stats["health"].Min)
+			assert.Equal(t, 500, *rules.Stats["health"].Max)
+			assert.Equal(t, 100, *rules.Stats["critical_odds"].Max)
+			assert.Equal(t, StatBudget{Weights: map[string]int{"damage": 4, "health": 1}, Cap: 400}, *rules.Budget)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SchemaController struct{}
+
+func NewSchemaController() SchemaController {
+	return SchemaController{}
+}
+
+func (c SchemaController) Mount(router fiber.Router) {
+	router.Get("/schemas/reactor", c.GetReactorSchema)
+}
+
+// GetReactorSchema serves the JSON Schema skill reactors are checked
+// against, for editors to offer completion with.
+func (c SchemaController) GetReactorSchema(fc *fiber.Ctx) error {
+	fc.Set(fiber.HeaderContentType, "application/schema+json")
+	return fc.Send(reactor.Schema)
+}
@@ -0,0 +1,30 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaController_GetReactorSchema(t *testing.T) {
+	app := fiber.New()
+	NewSchemaController().Mount(app)
+	req := httptest.NewRequest("GET", "/schemas/reactor", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/schema+json", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var schema map[string]any
+	assert.NoError(t, json.Unmarshal(body, &schema))
+	assert.Equal(t, "Reactor", schema["title"])
+}
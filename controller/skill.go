@@ -2,69 +2,121 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/reactor"
 	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
 	"github.com/farseeingnorthwest/playground/battlefield/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
 )
 
 type SkillController struct {
-	repo SkillRepository
+	repo     SkillRepository
+	tagTypes *tagtype.Registry
 }
 
 type SkillRepository interface {
 	Find(ids ...int) ([]storage.SkillMeta, error)
+	Query(q storage.SkillQuery) ([]storage.SkillMeta, string, error)
 	FindEx(ids ...int) ([]storage.Skill, error)
+	FindExAsOf(asOf time.Time, ids ...int) ([]storage.Skill, error)
 	Create(skill *storage.Skill) error
 	Get(id int) (*storage.Skill, error)
 	Update(skill *storage.Skill) error
-	Delete(id int, force bool) error
+	Delete(id, revision int, force bool) error
+	Duplicates(id int, hash string) ([]storage.SkillMeta, error)
+	Dependents(id int) ([]storage.SkillDependent, error)
+	Trash() ([]storage.Skill, error)
+	Restore(id int) (*storage.Skill, error)
+	Purge(id int) error
+	Revisions(id int) ([]storage.SkillRevision, error)
+	Revision(id, revision int) (*storage.SkillRevision, error)
 }
 
-func NewSkillController(repo SkillRepository) SkillController {
-	return SkillController{repo}
+func NewSkillController(repo SkillRepository, tagTypes *tagtype.Registry) SkillController {
+	return SkillController{repo, tagTypes}
 }
 
 func (c SkillController) Mount(router fiber.Router) {
 	router.Get("/skills", c.GetSkills)
 	router.Post("/skills", c.CreateSkill)
+	router.Post("/skills/validate", c.ValidateSkill)
+	router.Get("/skills/diff", c.DiffSkills)
 	router.Get("/skills/:id", c.GetSkill)
 	router.Put("/skills/:id", c.UpdateSkill)
+	router.Patch("/skills/:id", c.PatchSkill)
 	router.Delete("/skills/:id", c.DeleteSkill)
+	router.Get("/skills/:id/dependents", c.GetSkillDependents)
+	router.Get("/skills/:id/lint", c.LintSkill)
+	router.Get("/skills/:id/description", c.GetSkillDescription)
+	router.Get("/skills/:id/revisions", c.GetSkillRevisions)
+	router.Get("/skills/:id/revisions/:revision", c.GetSkillRevision)
+	router.Get("/skills/:id/revisions/:from/diff/:to", c.DiffSkillRevisions)
+	router.Post("/skills/:id/revisions/:revision/restore", c.RestoreSkillRevision)
+	router.Get("/trash/skills", c.GetTrashedSkills)
+	router.Post("/trash/skills/:id/restore", c.RestoreSkill)
+	router.Delete("/trash/skills/:id", c.PurgeSkill)
 }
 
+// GetSkills lists a page of skills, narrowed down by what their reactors
+// hold as parseSkillQuery reads.
 func (c SkillController) GetSkills(fc *fiber.Ctx) error {
-	skills, err := c.repo.Find()
+	q, err := parseSkillQuery(fc)
 	if err != nil {
 		return err
 	}
+	skills, next, err := c.repo.Query(q)
+	if err != nil {
+		return storageError(err)
+	}
 
+	setNextCursor(fc, next)
 	return fc.JSON(functional.MapSlice(newSkillMetaView, skills))
 }
 
+// CreateSkill creates the skill, warning about the skills identical to it but
+// for their names, if there are any.
 func (c SkillController) CreateSkill(fc *fiber.Ctx) error {
-	var form struct {
-		Name    string
-		Reactor battlefield.FatReactorFile
-	}
-	if err := fc.BodyParser(&form); err != nil {
+	var form skillForm
+	if err := bindSkill(fc, &form); err != nil {
 		return err
 	}
 
-	skill := storage.Skill{
-		SkillMeta: storage.SkillMeta{
-			Name: form.Name,
-		},
-		Reactor: (*storage.Reactor)(form.Reactor.FatReactor),
+	skill, err := form.skill(0, c.tagTypes)
+	if err != nil {
+		return err
 	}
+
+	skill.Author = author(fc)
 	if err := c.repo.Create(&skill); err != nil {
-		return err
+		return storageError(err)
 	}
 
+	// The skill is created by now, so failing to tell its duplicates only
+	// leaves out the warning.
+	duplicates, err := c.repo.Duplicates(skill.ID, skill.ReactorHash)
+	if err != nil {
+		log.Errorf("skills/%d: duplicates: %v", skill.ID, err)
+	} else if len(duplicates) > 0 {
+		identical := functional.MapSlice(func(s storage.SkillMeta) string {
+			return fmt.Sprintf("skills/%d (%s)", s.ID, s.Name)
+		}, duplicates)
+		fc.Set(fiber.HeaderWarning, "299 - "+strconv.Quote("identical to "+strings.Join(identical, ", ")))
+	}
+
+	setETag(fc, skill.Revision)
 	return fc.JSON(skillView(skill))
 }
 
+// GetSkill sends the skill as JSON, or in the skill notation when it is
+// preferred by Accept.
 func (c SkillController) GetSkill(fc *fiber.Ctx) error {
 	id, err := fc.ParamsInt("id")
 	if err != nil {
@@ -72,9 +124,13 @@ func (c SkillController) GetSkill(fc *fiber.Ctx) error {
 	}
 	skill, err := c.repo.Get(id)
 	if err != nil {
-		return err
+		return storageError(err)
 	}
 
+	setETag(fc, skill.Revision)
+	if fc.Accepts(fiber.MIMEApplicationJSON, mimeSkillNotation) == mimeSkillNotation {
+		return sendSkillNotation(fc, skill)
+	}
 	return fc.JSON((*skillView)(skill))
 }
 
@@ -83,26 +139,84 @@ func (c SkillController) UpdateSkill(fc *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-
-	var form struct {
-		Name    string
-		Reactor battlefield.FatReactorFile
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
 	}
-	if err := fc.BodyParser(&form); err != nil {
+
+	var form skillForm
+	if err := bindSkill(fc, &form); err != nil {
 		return err
 	}
 
-	skill := storage.Skill{
-		SkillMeta: storage.SkillMeta{
-			ID:   id,
-			Name: form.Name,
-		},
-		Reactor: (*storage.Reactor)(form.Reactor.FatReactor),
+	skill, err := form.skill(id, c.tagTypes)
+	if err != nil {
+		return err
 	}
+
+	skill.Revision = revision
+	skill.Author = author(fc)
 	if err := c.repo.Update(&skill); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, skill.Revision)
+	return fc.JSON(skillView(skill))
+}
+
+// PatchSkill applies a JSON merge patch to the skill. A patch in the skill
+// notation replaces the reactor rather than merging into it.
+func (c SkillController) PatchSkill(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+	patch, replace, err := skillPatchBody(fc)
+	if err != nil {
 		return err
 	}
 
+	current, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+	if revision != 0 && revision != current.Revision {
+		return storageError(storage.ErrStale)
+	}
+	doc, err := json.Marshal(skillView(*current))
+	if err != nil {
+		return err
+	}
+	if replace {
+		if doc, err = mergePatch(doc, []byte(`{"reactor":null}`)); err != nil {
+			return err
+		}
+	}
+	if doc, err = mergePatch(doc, patch); err != nil {
+		return err
+	}
+
+	var form skillForm
+	if err := json.Unmarshal(doc, &form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	skill, err := form.skill(id, c.tagTypes)
+	if err != nil {
+		return err
+	}
+
+	skill.Revision = current.Revision
+	skill.Author = author(fc)
+	if err := c.repo.Update(&skill); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, skill.Revision)
 	return fc.JSON(skillView(skill))
 }
 
@@ -111,13 +225,164 @@ func (c SkillController) DeleteSkill(fc *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	if err := c.repo.Delete(id, false); err != nil {
+	revision, err := ifMatch(fc)
+	if err != nil {
 		return err
 	}
+	if err := c.repo.Delete(id, revision, fc.QueryBool("force")); err != nil {
+		return storageError(err)
+	}
 
 	return fc.SendStatus(fiber.StatusNoContent)
 }
 
+// GetSkillDependents lists the character slots holding the skill, which a
+// DELETE with ?force=true would make look empty. Skills in the trash are not
+// found.
+func (c SkillController) GetSkillDependents(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	dependents, err := c.repo.Dependents(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON(functional.MapSlice(newSkillDependentView, dependents))
+}
+
+// LintSkill lists the authoring mistakes found in the reactor of the skill.
+func (c SkillController) LintSkill(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	skill, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+	doc, err := skill.Reactor.Document()
+	if err != nil {
+		return err
+	}
+
+	findings := reactor.Lint(doc)
+	if findings == nil {
+		findings = []reactor.Finding{}
+	}
+
+	return fc.JSON(findings)
+}
+
+// GetSkillDescription describes the skill in words, in the language asked
+// for by ?lang= or else by Accept-Language, English by default.
+func (c SkillController) GetSkillDescription(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	lang := fc.Query("lang")
+	if lang == "" {
+		lang = fc.AcceptsLanguages(reactor.Languages...)
+	}
+	if lang == "" {
+		lang = reactor.Languages[0]
+	}
+	if !slices.Contains(reactor.Languages, lang) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown language %q", lang))
+	}
+
+	skill, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+	description, err := describeSkill(skill.Reactor, lang)
+	if err != nil {
+		return err
+	}
+
+	return fc.JSON(map[string]any{
+		"lang":        lang,
+		"description": description,
+	})
+}
+
+type skillForm struct {
+	Name       string           `json:"name"`
+	SlotType   storage.SlotType `json:"slot_type"`
+	Reactor    reactorForm      `json:"reactor"`
+	TemplateID *int             `json:"template_id"`
+	Params     map[string]any   `json:"params"`
+}
+
+// reactorForm decodes a reactor, keeping its JSON for the checks the
+// battlefield does not do.
+type reactorForm struct {
+	battlefield.FatReactorFile
+	raw []byte
+}
+
+func (f *reactorForm) UnmarshalJSON(b []byte) error {
+	f.raw = append(f.raw[:0], b...)
+	return f.FatReactorFile.UnmarshalJSON(b)
+}
+
+// skill builds the skill described by the form, whose reactor is required
+// and must conform to the reactor schema. Skills fit special slots unless
+// told otherwise. A skill derived from a template gets its reactor rendered
+// from it by the repository, and the reactor in the form is ignored.
+func (f skillForm) skill(id int, tagTypes *tagtype.Registry) (storage.Skill, error) {
+	slotType := f.SlotType
+	if slotType == "" {
+		slotType = storage.SlotSpecial
+	}
+	if !slotType.Valid() {
+		return storage.Skill{}, fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("unknown slot type %q", slotType))
+	}
+	if f.TemplateID != nil {
+		return storage.Skill{
+			SkillMeta: storage.SkillMeta{
+				ID:       id,
+				Name:     f.Name,
+				SlotType: slotType,
+			},
+			TemplateID: f.TemplateID,
+			Params:     f.Params,
+		}, nil
+	}
+	if len(f.Reactor.raw) == 0 || string(f.Reactor.raw) == "null" {
+		return storage.Skill{}, fiber.NewError(fiber.StatusUnprocessableEntity, "reactor is required")
+	}
+	doc, err := reactor.Unmarshal(f.Reactor.raw)
+	if err != nil {
+		return storage.Skill{}, err
+	}
+	if problems := append(reactor.Validate(doc), tagTypes.Check(doc)...); len(problems) > 0 {
+		return storage.Skill{}, fiber.NewError(fiber.StatusUnprocessableEntity, strings.Join(problems, "; "))
+	}
+
+	return storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       id,
+			Name:     f.Name,
+			SlotType: slotType,
+		},
+		Reactor: (*storage.Reactor)(f.Reactor.FatReactor),
+	}, nil
+}
+
+func describeSkill(r *storage.Reactor, lang string) (string, error) {
+	doc, err := r.Document()
+	if err != nil {
+		return "", err
+	}
+
+	return reactor.Describe(doc, lang)
+}
+
 type skillMetaView storage.SkillMeta
 
 func newSkillMetaView(skill storage.SkillMeta) skillMetaView {
@@ -126,8 +391,9 @@ func newSkillMetaView(skill storage.SkillMeta) skillMetaView {
 
 func (s skillMetaView) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]any{
-		"id":   s.ID,
-		"name": s.Name,
+		"id":        s.ID,
+		"name":      s.Name,
+		"slot_type": s.SlotType,
 	})
 }
 
@@ -138,11 +404,31 @@ func newSkillView(skill storage.Skill) skillView {
 }
 
 func (v skillView) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]any{
-		"id":      v.ID,
-		"name":    v.Name,
-		"reactor": (*battlefield.FatReactor)(v.Reactor),
-	})
+	description, err := describeSkill(v.Reactor, reactor.Languages[0])
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]any{
+		"id":          v.ID,
+		"name":        v.Name,
+		"slot_type":   v.SlotType,
+		"reactor":     (*battlefield.FatReactor)(v.Reactor),
+		"description": description,
+		"revision":    v.Revision,
+	}
+	if v.ReactorHash != "" {
+		m["reactor_hash"] = v.ReactorHash
+	}
+	if v.DeletedAt != nil {
+		m["deleted_at"] = v.DeletedAt
+	}
+	if v.TemplateID != nil {
+		m["template_id"] = *v.TemplateID
+		m["params"] = v.Params
+	}
+
+	return json.Marshal(m)
 }
 
 type bySkillMetaID []storage.SkillMeta
@@ -154,3 +440,19 @@ type bySkillID []storage.Skill
 
 func (s bySkillID) Len() int                       { return len(s) }
 func (s bySkillID) Get(i int) (int, storage.Skill) { return s[i].ID, s[i] }
+
+type skillDependentView storage.SkillDependent
+
+func newSkillDependentView(dependent storage.SkillDependent) skillDependentView {
+	return skillDependentView(dependent)
+}
+
+func (v skillDependentView) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"character": map[string]any{
+			"id":   v.CharacterID,
+			"name": v.CharacterName,
+		},
+		"slot": v.Slot,
+	})
+}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/playground/battlefield/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (c SkillController) GetSkillRevisions(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revisions, err := c.repo.Revisions(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON(functional.MapSlice(newSkillRevisionView, revisions))
+}
+
+func (c SkillController) GetSkillRevision(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := fc.ParamsInt("revision")
+	if err != nil {
+		return err
+	}
+	rev, err := c.repo.Revision(id, revision)
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON((*skillRevisionView)(rev))
+}
+
+// DiffSkillRevisions compares the reactors of two revisions of a skill, as
+// DiffSkills does.
+func (c SkillController) DiffSkillRevisions(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	var sides [2]skillSide
+	var docs [2]any
+	for i, param := range []string{"from", "to"} {
+		revision, err := fc.ParamsInt(param)
+		if err != nil {
+			return err
+		}
+		if sides[i], docs[i], err = c.comparisonSide(id, revision); err != nil {
+			return err
+		}
+	}
+
+	return fc.JSON(skillComparisonView{
+		A:          sides[0],
+		B:          sides[1],
+		Comparison: reactor.Compare(docs[0], docs[1]),
+	})
+}
+
+// DiffSkills compares the reactors of skills ?a= and ?b=, or of revisions
+// of them given by ?a_revision= and ?b_revision=, the current ones by
+// default. Tags, triggers and numbers changed are told apart from the rest.
+func (c SkillController) DiffSkills(fc *fiber.Ctx) error {
+	var sides [2]skillSide
+	var docs [2]any
+	for i, param := range []string{"a", "b"} {
+		id := fc.QueryInt(param)
+		if id <= 0 {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid %s %q", param, fc.Query(param)))
+		}
+
+		var err error
+		if sides[i], docs[i], err = c.comparisonSide(id, fc.QueryInt(param+"_revision")); err != nil {
+			return err
+		}
+	}
+
+	return fc.JSON(skillComparisonView{
+		A:          sides[0],
+		B:          sides[1],
+		Comparison: reactor.Compare(docs[0], docs[1]),
+	})
+}
+
+// comparisonSide loads a revision of a skill, the current one if zero, and
+// decodes its reactor for a comparison.
+func (c SkillController) comparisonSide(id, revision int) (skillSide, any, error) {
+	var side skillSide
+	var r *storage.Reactor
+	if revision > 0 {
+		rev, err := c.repo.Revision(id, revision)
+		if err != nil {
+			return side, nil, storageError(err)
+		}
+		side, r = skillSide{id, revision, rev.Name}, rev.Reactor
+	} else {
+		skill, err := c.repo.Get(id)
+		if err != nil {
+			return side, nil, storageError(err)
+		}
+		side, r = skillSide{id, skill.Revision, skill.Name}, skill.Reactor
+	}
+
+	doc, err := r.Document()
+	if err != nil {
+		return side, nil, err
+	}
+
+	return side, doc, nil
+}
+
+// RestoreSkillRevision makes an old revision current again, as a new
+// revision on top of the history. A revision derived from a template is
+// rendered again from the template as it is now, with the parameters of
+// the revision.
+func (c SkillController) RestoreSkillRevision(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := fc.ParamsInt("revision")
+	if err != nil {
+		return err
+	}
+	current, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+
+	rev, err := c.repo.Revision(id, revision)
+	if err != nil {
+		return storageError(err)
+	}
+
+	skill := storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       id,
+			Name:     rev.Name,
+			SlotType: rev.SlotType,
+		},
+		Reactor:    rev.Reactor,
+		TemplateID: rev.TemplateID,
+		Params:     rev.Params,
+		Revision:   current,
+		Author:     author(fc),
+	}
+	if err := c.repo.Update(&skill); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, skill.Revision)
+	return fc.JSON(skillView(skill))
+}
+
+// author names whoever makes the change, as told by the X-Author header.
+func author(fc *fiber.Ctx) string {
+	return fc.Get("X-Author")
+}
+
+type skillRevisionView storage.SkillRevision
+
+func newSkillRevisionView(rev storage.SkillRevision) skillRevisionView {
+	return skillRevisionView(rev)
+}
+
+func (v skillRevisionView) MarshalJSON() ([]byte, error) {
+	m := map[string]any{
+		"skill_id":   v.SkillID,
+		"revision":   v.Revision,
+		"name":       v.Name,
+		"slot_type":  v.SlotType,
+		"author":     v.Author,
+		"created_at": v.CreatedAt.Format(time.RFC3339),
+	}
+	if v.Reactor != nil {
+		m["reactor"] = (*battlefield.FatReactor)(v.Reactor)
+	}
+	if v.TemplateID != nil {
+		m["template_id"] = *v.TemplateID
+		m["params"] = v.Params
+	}
+
+	return json.Marshal(m)
+}
+
+// skillSide is the revision of a skill on one side of a comparison.
+type skillSide struct {
+	ID       int    `json:"id"`
+	Revision int    `json:"revision"`
+	Name     string `json:"name"`
+}
+
+type skillComparisonView struct {
+	A, B skillSide
+	reactor.Comparison
+}
+
+func (v skillComparisonView) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"a":        v.A,
+		"b":        v.B,
+		"tags":     v.Tags,
+		"triggers": v.Triggers,
+		"values":   v.Values,
+		"others":   v.Others,
+	})
+}
@@ -0,0 +1,191 @@
+package controller_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/farseeingnorthwest/playground/battlefield/v2/examples"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSkillController_GetSkillRevisions(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Revisions", 1).Return([]storage.SkillRevision{
+		{
+			SkillID:   1,
+			Revision:  2,
+			Name:      "Normal Attack",
+			SlotType:  storage.SlotNormal,
+			Author:    "oda",
+			CreatedAt: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills/1/revisions", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"skill_id":1,"revision":2,"name":"Normal Attack","slot_type":"normal","author":"oda","created_at":"2026-09-01T00:00:00Z"}]`, string(body))
+}
+
+func TestSkillController_DiffSkillRevisions(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Revision", 1, 1).Return(&storage.SkillRevision{
+		SkillID:  1,
+		Revision: 1,
+		Name:     "Normal Attack",
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+	}, nil)
+	r.On("Revision", 1, 2).Return(&storage.SkillRevision{
+		SkillID:  1,
+		Revision: 2,
+		Name:     "Strike",
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+	}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills/1/revisions/1/diff/2", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":{"id":1,"revision":1,"name":"Normal Attack"},"b":{"id":1,"revision":2,"name":"Strike"},"tags":[],"triggers":[],"values":[],"others":[]}`, string(body))
+}
+
+func TestSkillController_DiffSkills(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Get", 1).Return(&storage.Skill{
+		SkillMeta: storage.SkillMeta{ID: 1, Name: "Normal Attack"},
+		Reactor:   (*storage.Reactor)(examples.Regular[0]),
+		Revision:  3,
+	}, nil)
+	r.On("Revision", 1, 2).Return(&storage.SkillRevision{
+		SkillID:  1,
+		Revision: 2,
+		Name:     "Strike",
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+	}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	for _, tt := range []struct {
+		query  string
+		status int
+		want   string
+	}{
+		{
+			"?a=1&a_revision=2&b=1",
+			fiber.StatusOK,
+			`{"a":{"id":1,"revision":2,"name":"Strike"},"b":{"id":1,"revision":3,"name":"Normal Attack"},"tags":[],"triggers":[],"values":[],"others":[]}`,
+		},
+		{"?a=1", fiber.StatusBadRequest, `invalid b ""`},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/skills/diff"+tt.query, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			if tt.status == fiber.StatusOK {
+				assert.JSONEq(t, tt.want, string(body))
+			} else {
+				assert.Equal(t, tt.want, string(body))
+			}
+		})
+	}
+}
+
+func TestSkillController_RestoreSkillRevision(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Revision", 1, 1).Return(&storage.SkillRevision{
+		SkillID:  1,
+		Revision: 1,
+		Name:     "Normal Attack",
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+	}, nil)
+	r.On("Update", &storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:   1,
+			Name: "Normal Attack",
+		},
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+		Revision: 3,
+		Author:   "ueno",
+	}).Run(func(args mock.Arguments) {
+		args.Get(0).(*storage.Skill).Revision = 4
+	}).Return(nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills/1/revisions/1/restore", nil)
+	req.Header.Set("If-Match", `"3"`)
+	req.Header.Set("X-Author", "ueno")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+}
+
+func TestSkillController_RestoreSkillRevision_Derived(t *testing.T) {
+	templateID := 1
+	r := new(mockSkillRepository)
+	r.On("Revision", 5, 1).Return(&storage.SkillRevision{
+		SkillID:    5,
+		Revision:   1,
+		Name:       "Strike",
+		SlotType:   storage.SlotSpecial,
+		Reactor:    (*storage.Reactor)(examples.Regular[0]),
+		TemplateID: &templateID,
+		Params:     storage.ParamValues{"multiplier": json.Number("150")},
+	}, nil)
+	r.On("Update", &storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       5,
+			Name:     "Strike",
+			SlotType: storage.SlotSpecial,
+		},
+		Reactor:    (*storage.Reactor)(examples.Regular[0]),
+		TemplateID: &templateID,
+		Params:     storage.ParamValues{"multiplier": json.Number("150")},
+		Revision:   2,
+	}).Run(func(args mock.Arguments) {
+		args.Get(0).(*storage.Skill).Revision = 3
+	}).Return(nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills/5/revisions/1/restore", nil)
+	req.Header.Set("If-Match", `"2"`)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"template_id":1`)
+}
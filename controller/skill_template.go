@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SkillTemplateController struct {
+	repo     SkillTemplateRepository
+	tagTypes *tagtype.Registry
+}
+
+type SkillTemplateRepository interface {
+	Find() ([]storage.SkillTemplate, error)
+	Get(id int) (*storage.SkillTemplate, error)
+	Create(t *storage.SkillTemplate) error
+	Update(t *storage.SkillTemplate) error
+	Delete(id, revision int, force bool) error
+	Derived(id int) ([]storage.Skill, error)
+	UpdateParams(id int, params map[int]storage.ParamValues, author string) ([]storage.Skill, error)
+}
+
+func NewSkillTemplateController(repo SkillTemplateRepository, tagTypes *tagtype.Registry) SkillTemplateController {
+	return SkillTemplateController{repo, tagTypes}
+}
+
+func (c SkillTemplateController) Mount(router fiber.Router) {
+	router.Get("/skill-templates", c.GetSkillTemplates)
+	router.Post("/skill-templates", c.CreateSkillTemplate)
+	router.Get("/skill-templates/:id", c.GetSkillTemplate)
+	router.Put("/skill-templates/:id", c.UpdateSkillTemplate)
+	router.Delete("/skill-templates/:id", c.DeleteSkillTemplate)
+	router.Get("/skill-templates/:id/skills", c.GetDerivedSkills)
+	router.Patch("/skill-templates/:id/skills", c.UpdateDerivedSkills)
+}
+
+func (c SkillTemplateController) GetSkillTemplates(fc *fiber.Ctx) error {
+	templates, err := c.repo.Find()
+	if err != nil {
+		return err
+	}
+
+	return fc.JSON(functional.MapSlice(newSkillTemplateView, templates))
+}
+
+func (c SkillTemplateController) CreateSkillTemplate(fc *fiber.Ctx) error {
+	t, err := c.form(fc, 0)
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Create(t); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, t.Revision)
+	return fc.JSON(skillTemplateView(*t))
+}
+
+func (c SkillTemplateController) GetSkillTemplate(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	t, err := c.repo.Get(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, t.Revision)
+	return fc.JSON(skillTemplateView(*t))
+}
+
+// UpdateSkillTemplate overwrites the template and renders the skills derived
+// from it again, none of them if any no longer renders.
+func (c SkillTemplateController) UpdateSkillTemplate(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+
+	t, err := c.form(fc, id)
+	if err != nil {
+		return err
+	}
+	t.Revision = revision
+	t.Author = author(fc)
+	if err := c.repo.Update(t); err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, t.Revision)
+	return fc.JSON(skillTemplateView(*t))
+}
+
+func (c SkillTemplateController) DeleteSkillTemplate(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	revision, err := ifMatch(fc)
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Delete(id, revision, fc.QueryBool("force")); err != nil {
+		return storageError(err)
+	}
+
+	return fc.SendStatus(fiber.StatusNoContent)
+}
+
+// GetDerivedSkills lists the skills derived from the template.
+func (c SkillTemplateController) GetDerivedSkills(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	skills, err := c.repo.Derived(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON(functional.MapSlice(newSkillView, skills))
+}
+
+// UpdateDerivedSkills gives new parameter values to skills derived from the
+// template, listed in the body with their IDs. The values are merged into
+// the ones of each skill, nulls falling back to the defaults.
+func (c SkillTemplateController) UpdateDerivedSkills(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	var forms []struct {
+		ID     int            `json:"id"`
+		Params map[string]any `json:"params"`
+	}
+	if err := fc.BodyParser(&forms); err != nil {
+		return err
+	}
+
+	derived, err := c.repo.Derived(id)
+	if err != nil {
+		return storageError(err)
+	}
+	skills := functional.Tabulate[int, storage.Skill](bySkillID(derived))
+
+	params := make(map[int]storage.ParamValues, len(forms))
+	for _, form := range forms {
+		skill, ok := skills[form.ID]
+		if !ok {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("skill %d is not derived from skill template %d", form.ID, id))
+		}
+
+		values := make(storage.ParamValues, len(skill.Params)+len(form.Params))
+		for k, v := range skill.Params {
+			values[k] = v
+		}
+		for k, v := range form.Params {
+			if v == nil {
+				delete(values, k)
+			} else {
+				values[k] = v
+			}
+		}
+		params[form.ID] = values
+	}
+
+	updated, err := c.repo.UpdateParams(id, params, author(fc))
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON(functional.MapSlice(newSkillView, updated))
+}
+
+type skillTemplateForm struct {
+	Name     string                  `json:"name"`
+	SlotType storage.SlotType        `json:"slot_type"`
+	Reactor  storage.TemplateReactor `json:"reactor"`
+	Params   storage.TemplateParams  `json:"params"`
+}
+
+// form builds the template described by the request body, whose
+// placeholders must all be declared parameters. Templates fit special slots
+// unless told otherwise.
+func (c SkillTemplateController) form(fc *fiber.Ctx, id int) (*storage.SkillTemplate, error) {
+	var form skillTemplateForm
+	if err := fc.BodyParser(&form); err != nil {
+		return nil, err
+	}
+
+	slotType := form.SlotType
+	if slotType == "" {
+		slotType = storage.SlotSpecial
+	}
+	if !slotType.Valid() {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("unknown slot type %q", slotType))
+	}
+
+	if form.Reactor == nil {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "$: no reactor")
+	}
+	doc, err := reactor.Unmarshal(form.Reactor)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "$: reactor must be an object")
+	}
+	problems := append(reactor.CheckTemplate(doc, reactor.Params(form.Params)), c.tagTypes.Check(doc)...)
+	if len(problems) > 0 {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, strings.Join(problems, "; "))
+	}
+
+	return &storage.SkillTemplate{
+		ID:       id,
+		Name:     form.Name,
+		SlotType: slotType,
+		Reactor:  form.Reactor,
+		Params:   form.Params,
+	}, nil
+}
+
+type skillTemplateView storage.SkillTemplate
+
+func newSkillTemplateView(t storage.SkillTemplate) skillTemplateView {
+	return skillTemplateView(t)
+}
+
+func (v skillTemplateView) MarshalJSON() ([]byte, error) {
+	params := v.Params
+	if params == nil {
+		params = storage.TemplateParams{}
+	}
+
+	return json.Marshal(map[string]any{
+		"id":        v.ID,
+		"name":      v.Name,
+		"slot_type": v.SlotType,
+		"reactor":   v.Reactor,
+		"params":    params,
+		"revision":  v.Revision,
+	})
+}
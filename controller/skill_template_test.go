@@ -0,0 +1,158 @@
+package controller_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const strikeTemplate = `{"name":"Strike","params":{"multiplier":{"type":"integer","default":100}},"reactor":{"tags":[{"_kind":"label","text":"Strike"}],"respond":{"when":{"signal":"launch"},"then":{"_kind":"verb","verb":{"_verb":"attack"},"evaluator":{"_kind":"multiplier","multiplier":{"_param":"multiplier"},"evaluator":{"_kind":"axis","axis":"damage"}}}}}}`
+
+func TestSkillTemplateController_CreateSkillTemplate(t *testing.T) {
+	r := new(mockSkillTemplateRepository)
+	r.On("Create", mock.MatchedBy(func(t *storage.SkillTemplate) bool {
+		return t.Name == "Strike" && t.SlotType == storage.SlotSpecial && t.Params["multiplier"].Type == "integer"
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*storage.SkillTemplate).ID = 1
+		args.Get(0).(*storage.SkillTemplate).Revision = 1
+	}).Return(nil)
+
+	app := fiber.New()
+	NewSkillTemplateController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skill-templates", strings.NewReader(strikeTemplate))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"params":{"multiplier":{"type":"integer","default":100}}`)
+}
+
+func TestSkillTemplateController_CreateSkillTemplate_UnknownParam(t *testing.T) {
+	r := new(mockSkillTemplateRepository)
+
+	app := fiber.New()
+	NewSkillTemplateController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skill-templates", strings.NewReader(strings.Replace(strikeTemplate, `"multiplier":{"type"`, `"ratio":{"type"`, 1)))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `$.respond.then.evaluator.multiplier: unknown parameter "multiplier"`, string(body))
+}
+
+func TestSkillTemplateController_UpdateSkillTemplate(t *testing.T) {
+	r := new(mockSkillTemplateRepository)
+	r.On("Update", mock.MatchedBy(func(t *storage.SkillTemplate) bool {
+		return t.ID == 1 && t.Revision == 3
+	})).Return(storage.ErrTemplate)
+
+	app := fiber.New()
+	NewSkillTemplateController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("PUT", "/skill-templates/1", strings.NewReader(strikeTemplate))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"3"`)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestSkillTemplateController_UpdateDerivedSkills(t *testing.T) {
+	templateID := 1
+	derived := []storage.Skill{
+		{
+			SkillMeta:  storage.SkillMeta{ID: 3, Name: "Strike", SlotType: storage.SlotSpecial},
+			TemplateID: &templateID,
+			Params:     storage.ParamValues{"multiplier": 150, "targets": 2},
+		},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"merge", `[{"id":3,"params":{"multiplier":200,"targets":null}}]`, fiber.StatusOK},
+		{"not derived", `[{"id":4,"params":{"multiplier":200}}]`, fiber.StatusUnprocessableEntity},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := new(mockSkillTemplateRepository)
+			r.On("Derived", 1).Return(derived, nil)
+			r.On("UpdateParams", 1, map[int]storage.ParamValues{3: {"multiplier": float64(200)}}, "").
+				Return([]storage.Skill{derived[0]}, nil)
+
+			app := fiber.New()
+			NewSkillTemplateController(r, new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest("PATCH", "/skill-templates/1/skills", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == fiber.StatusOK {
+				r.AssertExpectations(t)
+			} else {
+				r.AssertNotCalled(t, "UpdateParams", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+type mockSkillTemplateRepository struct {
+	mock.Mock
+}
+
+func (r *mockSkillTemplateRepository) Find() ([]storage.SkillTemplate, error) {
+	args := r.Called()
+	return args.Get(0).([]storage.SkillTemplate), args.Error(1)
+}
+
+func (r *mockSkillTemplateRepository) Get(id int) (*storage.SkillTemplate, error) {
+	args := r.Called(id)
+	return args.Get(0).(*storage.SkillTemplate), args.Error(1)
+}
+
+func (r *mockSkillTemplateRepository) Create(t *storage.SkillTemplate) error {
+	args := r.Called(t)
+	return args.Error(0)
+}
+
+func (r *mockSkillTemplateRepository) Update(t *storage.SkillTemplate) error {
+	args := r.Called(t)
+	return args.Error(0)
+}
+
+func (r *mockSkillTemplateRepository) Delete(id, revision int, force bool) error {
+	args := r.Called(id, revision, force)
+	return args.Error(0)
+}
+
+func (r *mockSkillTemplateRepository) Derived(id int) ([]storage.Skill, error) {
+	args := r.Called(id)
+	return args.Get(0).([]storage.Skill), args.Error(1)
+}
+
+func (r *mockSkillTemplateRepository) UpdateParams(id int, params map[int]storage.ParamValues, author string) ([]storage.Skill, error) {
+	args := r.Called(id, params, author)
+	return args.Get(0).([]storage.Skill), args.Error(1)
+}
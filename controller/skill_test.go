@@ -1,13 +1,19 @@
 package controller_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/reactor"
 	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
 	"github.com/farseeingnorthwest/playground/battlefield/v2/examples"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -16,15 +22,16 @@ import (
 
 func TestSkillController_GetSkills(t *testing.T) {
 	r := new(mockSkillRepository)
-	r.On("Find", []int(nil)).Return([]storage.SkillMeta{
+	r.On("Query", storage.SkillQuery{Query: storage.Query{Limit: 100}}).Return([]storage.SkillMeta{
 		{
-			ID:   1,
-			Name: "Normal Attack",
+			ID:       1,
+			Name:     "Normal Attack",
+			SlotType: storage.SlotNormal,
 		},
-	}, nil)
+	}, "", nil)
 
 	app := fiber.New()
-	NewSkillController(r).Mount(app)
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("GET", "/skills", nil)
 	resp, err := app.Test(req)
 
@@ -33,22 +40,72 @@ func TestSkillController_GetSkills(t *testing.T) {
 
 	body, err := io.ReadAll(resp.Body)
 	assert.NoError(t, err)
-	assert.JSONEq(t, `[{"id":1,"name":"Normal Attack"}]`, string(body))
+	assert.JSONEq(t, `[{"id":1,"name":"Normal Attack","slot_type":"normal"}]`, string(body))
+}
+
+func TestSkillController_GetSkills_Query(t *testing.T) {
+	for _, tt := range []struct {
+		query  string
+		status int
+	}{
+		{"?limit=0", fiber.StatusBadRequest},
+		{"?ids=1,x", fiber.StatusBadRequest},
+		{"?sort=reactor", fiber.StatusBadRequest},
+		{"?exclusion_group=x", fiber.StatusBadRequest},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			r := new(mockSkillRepository)
+			r.On("Query", storage.SkillQuery{Query: storage.Query{Sort: []storage.Order{{Key: "reactor"}}, Limit: 100}}).
+				Return([]storage.SkillMeta(nil), "", storage.ErrInvalidQuery)
+
+			app := fiber.New()
+			NewSkillController(r, new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest("GET", "/skills"+tt.query, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestSkillController_GetSkills_Reactor(t *testing.T) {
+	group := 0
+	r := new(mockSkillRepository)
+	r.On("Query", storage.SkillQuery{
+		Query:          storage.Query{Limit: 100},
+		Label:          "Sleep",
+		ExclusionGroup: &group,
+		Priority:       []storage.IndexRange{{Comparator: ">=", Value: 5}, {Comparator: "<", Value: 20}},
+		Verbs:          []string{"attack", "heal"},
+		Signals:        []string{"round_end"},
+	}).Return([]storage.SkillMeta{}, "", nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills?label=Sleep&exclusion_group=0&priority>=5&priority<20&verb=attack,heal&signal=round_end", nil)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
 }
 
 func TestSkillController_CreateSkill(t *testing.T) {
 	r := new(mockSkillRepository)
 	r.On("Create", &storage.Skill{
 		SkillMeta: storage.SkillMeta{
-			Name: "Sleep",
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
 		},
 		Reactor: (*storage.Reactor)(examples.Effect["Sleep"]),
 	}).Run(func(args mock.Arguments) {
 		args.Get(0).(*storage.Skill).ID = 1
 	}).Return(nil)
+	r.On("Duplicates", 1, "").Return([]storage.SkillMeta(nil), nil)
 
 	app := fiber.New()
-	NewSkillController(r).Mount(app)
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("POST", "/skills", strings.NewReader(
 		`{"name":"Sleep","reactor":{"tags":[{"_kind":"exclusion_group","index":0},{"_kind":"priority","index":10},{"_kind":"label","text":"Sleep"}],"capacity":{"count":1,"when":[{"signal":"round_end"},{"if":[{"_kind":"verb","verb":"attack"},{"_kind":"current_is_target"}],"signal":"post_action"}]},"respond":{"when":{"signal":"launch"},"then":{"_kind":"sequence","do":[]}}}}`))
 	req.Header.Set("Content-Type", "application/json")
@@ -63,18 +120,219 @@ func TestSkillController_CreateSkill(t *testing.T) {
 	assert.Contains(t, string(body), "Sleep")
 }
 
+func TestSkillController_CreateSkill_TagTypes(t *testing.T) {
+	r := new(mockSkillRepository)
+	tagTypes, err := tagtype.NewRegistry(storage.TagType{Name: "faction", Values: []string{"north", "south"}})
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	NewSkillController(r, tagTypes).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(
+		`{"name":"Sleep","reactor":{"tags":[{"_kind":"faction","value":"north"},{"_kind":"faction","value":"east"}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `$.tags[1]: faction has no value "east"`, string(body))
+}
+
+func TestSkillController_CreateSkill_Schema(t *testing.T) {
+	r := new(mockSkillRepository)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(
+		`{"name":"Sleep","reactor":{"tags":[{"_kind":"label"}],"capacity":{"count":1,"when":[{"signal":"turn_end"}]}}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `$.capacity.when[0].signal: value must be one of`)
+	assert.Contains(t, string(body), `$.tags[0]: missing properties: 'text'`)
+}
+
+func TestSkillController_CreateSkill_NoReactor(t *testing.T) {
+	r := new(mockSkillRepository)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "reactor is required", string(body))
+}
+
+func TestSkillController_CreateSkill_SlotType(t *testing.T) {
+	r := new(mockSkillRepository)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(
+		`{"name":"Sleep","slot_type":"ultimate","reactor":{"tags":[{"_kind":"label","text":"Sleep"}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestSkillController_CreateSkill_Notation(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Create", &storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
+		},
+		Reactor: (*storage.Reactor)(examples.Effect["Sleep"]),
+	}).Return(nil)
+	r.On("Duplicates", 0, "").Return([]storage.SkillMeta(nil), nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(`name: Sleep
+tags: [exclusion_group(index: 0), priority(index: 10), label(text: Sleep)]
+capacity: (
+  count: 1
+  when: [
+    (signal: round_end)
+    (signal: post_action, if: [verb(verb: attack), current_is_target()])
+  ]
+)
+respond: (when: (signal: launch), then: sequence(do: []))
+`))
+	req.Header.Set("Content-Type", "text/x-skill")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestSkillController_CreateSkill_Template(t *testing.T) {
+	templateID := 1
+	r := new(mockSkillRepository)
+	r.On("Create", &storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			Name:     "Strike",
+			SlotType: storage.SlotSpecial,
+		},
+		TemplateID: &templateID,
+		Params:     storage.ParamValues{"multiplier": float64(150)},
+	}).Return(nil)
+	r.On("Duplicates", 0, "").Return([]storage.SkillMeta(nil), nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(`{"name":"Strike","template_id":1,"params":{"multiplier":150}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"template_id":1`)
+}
+
+func TestSkillController_CreateSkill_Duplicate(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		skill := args.Get(0).(*storage.Skill)
+		skill.ID = 3
+		skill.ReactorHash = "5f9c"
+	}).Return(nil)
+	r.On("Duplicates", 3, "5f9c").Return([]storage.SkillMeta{{ID: 1, Name: "Normal Attack", SlotType: storage.SlotNormal}}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(
+		`{"name":"Basic Attack","slot_type":"normal","reactor":{"tags":[{"_kind":"label","text":"NormalAttack"}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, `299 - "identical to skills/1 (Normal Attack)"`, resp.Header.Get("Warning"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"reactor_hash":"5f9c"`)
+}
+
+func TestSkillController_CreateSkill_DuplicatesError(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		skill := args.Get(0).(*storage.Skill)
+		skill.ID = 3
+		skill.ReactorHash = "5f9c"
+	}).Return(nil)
+	r.On("Duplicates", 3, "5f9c").Return([]storage.SkillMeta(nil), errors.New("connection reset"))
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader(
+		`{"name":"Basic Attack","slot_type":"normal","reactor":{"tags":[{"_kind":"label","text":"NormalAttack"}]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Warning"))
+}
+
+func TestSkillController_CreateSkill_NotationSyntax(t *testing.T) {
+	r := new(mockSkillRepository)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("POST", "/skills", strings.NewReader("name: Sleep\ntags: [label(text: Sleep]\n"))
+	req.Header.Set("Content-Type", "text/x-skill; charset=utf-8")
+	resp, err := app.Test(req)
+
+	r.AssertNotCalled(t, "Create", mock.Anything)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `line 2, column 25: unexpected "]", expecting "," or ")"`, string(body))
+}
+
 func TestSkillController_GetSkill(t *testing.T) {
 	r := new(mockSkillRepository)
 	r.On("Get", 1).Return(&storage.Skill{
 		SkillMeta: storage.SkillMeta{
-			ID:   1,
-			Name: "Normal Attack",
+			ID:       1,
+			Name:     "Normal Attack",
+			SlotType: storage.SlotNormal,
 		},
 		Reactor: (*storage.Reactor)(examples.Regular[0]),
 	}, nil)
 
 	app := fiber.New()
-	NewSkillController(r).Mount(app)
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("GET", "/skills/1", nil)
 	resp, err := app.Test(req)
 
@@ -86,21 +344,56 @@ func TestSkillController_GetSkill(t *testing.T) {
 	assert.Contains(t, string(body), "NormalAttack")
 }
 
+func TestSkillController_GetSkill_Notation(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Get", 1).Return(&storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       1,
+			Name:     "Normal Attack",
+			SlotType: storage.SlotNormal,
+		},
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+		Revision: 2,
+	}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills/1", nil)
+	req.Header.Set("Accept", "text/x-skill")
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/x-skill; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(body), "name: \"Normal Attack\"\nslot_type: normal\n"))
+
+	doc, err := reactor.Parse(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "Normal Attack", doc.(map[string]any)["name"])
+}
+
 func TestSkillController_UpdateSkill(t *testing.T) {
 	r := new(mockSkillRepository)
 	r.On("Update", &storage.Skill{
 		SkillMeta: storage.SkillMeta{
-			ID:   1,
-			Name: "Sleep",
+			ID:       1,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
 		},
-		Reactor: (*storage.Reactor)(examples.Effect["Sleep"]),
+		Reactor:  (*storage.Reactor)(examples.Effect["Sleep"]),
+		Revision: 1,
 	}).Return(nil)
 
 	app := fiber.New()
-	NewSkillController(r).Mount(app)
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("PUT", "/skills/1", strings.NewReader(
 		`{"name":"Sleep","reactor":{"tags":[{"_kind":"exclusion_group","index":0},{"_kind":"priority","index":10},{"_kind":"label","text":"Sleep"}],"capacity":{"count":1,"when":[{"signal":"round_end"},{"if":[{"_kind":"verb","verb":"attack"},{"_kind":"current_is_target"}],"signal":"post_action"}]},"respond":{"when":{"signal":"launch"},"then":{"_kind":"sequence","do":[]}}}}`))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
 	resp, err := app.Test(req)
 
 	r.AssertExpectations(t)
@@ -112,13 +405,96 @@ func TestSkillController_UpdateSkill(t *testing.T) {
 	assert.Contains(t, string(body), "Sleep")
 }
 
+func TestSkillController_PatchSkill(t *testing.T) {
+	for _, tt := range []struct {
+		contentType string
+		status      int
+	}{
+		{"application/merge-patch+json", fiber.StatusOK},
+		{"application/json", fiber.StatusOK},
+		{"text/plain", fiber.StatusUnsupportedMediaType},
+	} {
+		t.Run(tt.contentType, func(t *testing.T) {
+			r := new(mockSkillRepository)
+			r.On("Get", 1).Return(&storage.Skill{
+				SkillMeta: storage.SkillMeta{
+					ID:       1,
+					Name:     "Normal Attack",
+					SlotType: storage.SlotNormal,
+				},
+				Reactor:  (*storage.Reactor)(examples.Regular[0]),
+				Revision: 4,
+			}, nil)
+			r.On("Update", mock.MatchedBy(func(skill *storage.Skill) bool {
+				return skill.ID == 1 && skill.Name == "Strike" && skill.Revision == 4
+			})).Return(nil)
+
+			app := fiber.New()
+			NewSkillController(r, new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest("PATCH", "/skills/1", strings.NewReader(`{"name":"Strike","reactor":{"capacity":null}}`))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("If-Match", `"4"`)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status == fiber.StatusOK {
+				r.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestSkillController_PatchSkill_Notation(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Get", 1).Return(&storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       1,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
+		},
+		Reactor:  (*storage.Reactor)(examples.Regular[0]),
+		Revision: 4,
+	}, nil)
+	r.On("Update", &storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       1,
+			Name:     "Sleep",
+			SlotType: storage.SlotSpecial,
+		},
+		Reactor:  (*storage.Reactor)(examples.Effect["Sleep"]),
+		Revision: 4,
+	}).Return(nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("PATCH", "/skills/1", strings.NewReader(`tags: [exclusion_group(index: 0), priority(index: 10), label(text: Sleep)]
+capacity: (
+  count: 1
+  when: [
+    (signal: round_end)
+    (signal: post_action, if: [verb(verb: attack), current_is_target()])
+  ]
+)
+respond: (when: (signal: launch), then: sequence(do: []))
+`))
+	req.Header.Set("Content-Type", "text/x-skill")
+	req.Header.Set("If-Match", `"4"`)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
 func TestSkillController_DeleteSkill(t *testing.T) {
 	r := new(mockSkillRepository)
-	r.On("Delete", 1).Return(nil)
+	r.On("Delete", 1, 0, false).Return(nil)
 
 	app := fiber.New()
-	NewSkillController(r).Mount(app)
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
 	req := httptest.NewRequest("DELETE", "/skills/1", nil)
+	req.Header.Set("If-Match", "*")
 	resp, err := app.Test(req)
 
 	r.AssertExpectations(t)
@@ -126,6 +502,131 @@ func TestSkillController_DeleteSkill(t *testing.T) {
 	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
 }
 
+func TestSkillController_DeleteSkill_Force(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Delete", 1, 0, false).Return(fmt.Errorf("skills/1: %w", storage.ErrInUse))
+	r.On("Delete", 1, 0, true).Return(nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	for _, tt := range []struct {
+		target string
+		status int
+	}{
+		{"/skills/1", fiber.StatusConflict},
+		{"/skills/1?force=true", fiber.StatusNoContent},
+	} {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", tt.target, nil)
+			req.Header.Set("If-Match", "*")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+	r.AssertExpectations(t)
+}
+
+func TestSkillController_GetSkillDependents(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Dependents", 1).Return([]storage.SkillDependent{
+		{CharacterID: 1, CharacterName: "Oda", Slot: 1},
+	}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills/1/dependents", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"character": {"id": 1, "name": "Oda"}, "slot": 1}]`, string(body))
+}
+
+func TestSkillController_GetSkillDependents_Trash(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Dependents", 1).Return([]storage.SkillDependent(nil), fmt.Errorf("skills/1: %w", storage.ErrNotFound))
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills/1/dependents", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestSkillController_LintSkill(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Get", 1).Return(&storage.Skill{
+		SkillMeta: storage.SkillMeta{
+			ID:       1,
+			Name:     "Normal Attack",
+			SlotType: storage.SlotNormal,
+		},
+		Reactor: (*storage.Reactor)(examples.Regular[0]),
+	}, nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/skills/1/lint", nil)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	var findings []reactor.Finding
+	assert.NoError(t, json.Unmarshal(body, &findings))
+}
+
+func TestSkillController_GetSkillDescription(t *testing.T) {
+	for _, tt := range []struct {
+		query, acceptLanguage string
+		status                int
+		lang                  string
+	}{
+		{"", "", fiber.StatusOK, "en"},
+		{"", "ja,en;q=0.5", fiber.StatusOK, "ja"},
+		{"?lang=en", "ja", fiber.StatusOK, "en"},
+		{"?lang=fr", "", fiber.StatusBadRequest, ""},
+	} {
+		t.Run(tt.query+tt.acceptLanguage, func(t *testing.T) {
+			r := new(mockSkillRepository)
+			r.On("Get", 1).Return(&storage.Skill{
+				SkillMeta: storage.SkillMeta{ID: 1, Name: "Normal Attack"},
+				Reactor:   (*storage.Reactor)(examples.Regular[0]),
+			}, nil)
+
+			app := fiber.New()
+			NewSkillController(r, new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest("GET", "/skills/1/description"+tt.query, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.status != fiber.StatusOK {
+				return
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			var v struct{ Lang string }
+			assert.NoError(t, json.Unmarshal(body, &v))
+			assert.Equal(t, tt.lang, v.Lang)
+		})
+	}
+}
+
 type mockSkillRepository struct {
 	mock.Mock
 }
@@ -135,11 +636,21 @@ func (r *mockSkillRepository) Find(ids ...int) ([]storage.SkillMeta, error) {
 	return args.Get(0).([]storage.SkillMeta), args.Error(1)
 }
 
+func (r *mockSkillRepository) Query(q storage.SkillQuery) ([]storage.SkillMeta, string, error) {
+	args := r.Called(q)
+	return args.Get(0).([]storage.SkillMeta), args.String(1), args.Error(2)
+}
+
 func (r *mockSkillRepository) FindEx(ids ...int) ([]storage.Skill, error) {
 	args := r.Called(ids)
 	return args.Get(0).([]storage.Skill), args.Error(1)
 }
 
+func (r *mockSkillRepository) FindExAsOf(asOf time.Time, ids ...int) ([]storage.Skill, error) {
+	args := r.Called(asOf, ids)
+	return args.Get(0).([]storage.Skill), args.Error(1)
+}
+
 func (r *mockSkillRepository) Create(skill *storage.Skill) error {
 	args := r.Called(skill)
 	return args.Error(0)
@@ -155,7 +666,42 @@ func (r *mockSkillRepository) Update(skill *storage.Skill) error {
 	return args.Error(0)
 }
 
-func (r *mockSkillRepository) Delete(id int, force bool) error {
+func (r *mockSkillRepository) Delete(id, revision int, force bool) error {
+	args := r.Called(id, revision, force)
+	return args.Error(0)
+}
+
+func (r *mockSkillRepository) Duplicates(id int, hash string) ([]storage.SkillMeta, error) {
+	args := r.Called(id, hash)
+	return args.Get(0).([]storage.SkillMeta), args.Error(1)
+}
+
+func (r *mockSkillRepository) Dependents(id int) ([]storage.SkillDependent, error) {
+	args := r.Called(id)
+	return args.Get(0).([]storage.SkillDependent), args.Error(1)
+}
+
+func (r *mockSkillRepository) Trash() ([]storage.Skill, error) {
+	args := r.Called()
+	return args.Get(0).([]storage.Skill), args.Error(1)
+}
+
+func (r *mockSkillRepository) Restore(id int) (*storage.Skill, error) {
+	args := r.Called(id)
+	return args.Get(0).(*storage.Skill), args.Error(1)
+}
+
+func (r *mockSkillRepository) Purge(id int) error {
 	args := r.Called(id)
 	return args.Error(0)
 }
+
+func (r *mockSkillRepository) Revisions(id int) ([]storage.SkillRevision, error) {
+	args := r.Called(id)
+	return args.Get(0).([]storage.SkillRevision), args.Error(1)
+}
+
+func (r *mockSkillRepository) Revision(id, revision int) (*storage.SkillRevision, error) {
+	args := r.Called(id, revision)
+	return args.Get(0).(*storage.SkillRevision), args.Error(1)
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/playground/battlefield/v2"
+	"github.com/farseeingnorthwest/playground/battlefield/v2/examples"
+	"github.com/gofiber/fiber/v2"
+)
+
+// sandboxDeadline bounds the sandbox battle, which is over long before in
+// most cases.
+const sandboxDeadline = 64
+
+// sandboxBaseline are the stats of every warrior of the sandbox battle.
+var sandboxBaseline = battlefield.MyBaseline{
+	Damage:       10,
+	CriticalOdds: 10,
+	CriticalLoss: 200,
+	Defense:      5,
+	Health:       100,
+	Speed:        10,
+}
+
+// ValidateSkill checks the reactor of the skill in the body, JSON or the
+// skill notation, which is not stored. It reports the unknown names found
+// with their JSON paths, or else where the reactor does not conform to the
+// reactor schema, or else tries the skill in a sandbox battle and reports
+// it if the skill never fires.
+func (c SkillController) ValidateSkill(fc *fiber.Ctx) error {
+	body, err := skillBody(fc)
+	if err != nil {
+		return err
+	}
+
+	var form struct {
+		Reactor json.RawMessage `json:"reactor"`
+	}
+	if err := json.Unmarshal(body, &form); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return fc.JSON(c.validate(form.Reactor))
+}
+
+func (c SkillController) validate(raw json.RawMessage) skillReport {
+	if len(raw) == 0 || string(raw) == "null" {
+		return skillReport{Problems: []string{"$: no reactor"}}
+	}
+
+	doc, err := reactor.Unmarshal(raw)
+	if err != nil {
+		return skillReport{Problems: []string{"$: " + err.Error()}}
+	}
+	problems := append(reactor.Check(doc, c.tagTypes.Names()...), c.tagTypes.Check(doc)...)
+	if len(problems) > 0 {
+		return skillReport{Problems: problems}
+	}
+	if problems := reactor.Validate(doc); len(problems) > 0 {
+		return skillReport{Problems: problems}
+	}
+
+	var f battlefield.FatReactorFile
+	if err := f.UnmarshalJSON(raw); err != nil {
+		return skillReport{Problems: []string{"$: " + err.Error()}}
+	}
+
+	fired, err := sandbox(f.FatReactor)
+	if err != nil {
+		return skillReport{Problems: []string{"$: " + err.Error()}}
+	}
+	if !fired {
+		return skillReport{Problems: []string{"$: never fired in the sandbox battle"}}
+	}
+
+	return skillReport{Fired: true}
+}
+
+// sandbox runs a battle of two warriors against two, all of them with
+// normal attacks, the first one with the skill too, and tells whether the
+// skill fired. A panic of the battlefield is returned as an error.
+func sandbox(skill *battlefield.FatReactor) (fired bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panicked in the sandbox battle: %v", r)
+		}
+	}()
+
+	spawn := func(r *battlefield.FatReactor) battlefield.Reactor {
+		return r.Fork(nil).(battlefield.Reactor)
+	}
+	ob := &sandboxObserver{
+		TagSet: battlefield.NewTagSet(battlefield.Priority(1000000)),
+		skill:  spawn(skill),
+	}
+
+	var warriors []battlefield.Warrior
+	for _, side := range []battlefield.Side{battlefield.Left, battlefield.Right} {
+		for p := 0; p < 2; p++ {
+			reactors := []battlefield.Reactor{spawn(examples.Regular[0])}
+			if side == battlefield.Left && p == 0 {
+				reactors = append(reactors, ob.skill)
+			}
+			warriors = append(warriors, battlefield.NewMyWarrior(
+				sandboxBaseline,
+				side,
+				p,
+				battlefield.WarriorSkills(reactors...),
+			))
+		}
+	}
+
+	f := battlefield.NewBattleField(
+		battlefield.JustRng{},
+		warriors,
+		battlefield.Deadline(sandboxDeadline),
+		battlefield.FieldReactor(ob),
+	)
+	f.Run()
+
+	return ob.fired, nil
+}
+
+// sandboxObserver watches the sandbox battle for actions taken by the skill
+// and for the skill being triggered.
+type sandboxObserver struct {
+	battlefield.TagSet
+	skill battlefield.Reactor
+	fired bool
+}
+
+func (o *sandboxObserver) React(signal battlefield.Signal, _ battlefield.EvaluationContext) {
+	switch signal := signal.(type) {
+	case *battlefield.PostActionSignal:
+		if _, _, r := signal.Action().Script().Source(); r == o.skill {
+			o.fired = true
+		}
+	case *battlefield.LifecycleSignal:
+		if _, r := signal.Source(); r == o.skill && signal.Affairs()&battlefield.LifecycleTrigger != 0 {
+			o.fired = true
+		}
+	}
+}
+
+func (o *sandboxObserver) Active() bool {
+	return true
+}
+
+// skillReport is the outcome of validating a skill: valid when no problem
+// is found.
+type skillReport struct {
+	Problems []string
+	Fired    bool
+}
+
+func (r skillReport) MarshalJSON() ([]byte, error) {
+	problems := r.Problems
+	if problems == nil {
+		problems = []string{}
+	}
+
+	return json.Marshal(map[string]any{
+		"valid":    len(r.Problems) == 0,
+		"problems": problems,
+		"fired":    r.Fired,
+	})
+}
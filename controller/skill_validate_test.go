@@ -0,0 +1,93 @@
+package controller_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkillController_ValidateSkill(t *testing.T) {
+	tagTypes, err := tagtype.NewRegistry(storage.TagType{Name: "faction", Values: []string{"north", "south"}})
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			"no reactor",
+			`{"name":"Sleep"}`,
+			`{"valid":false,"fired":false,"problems":["$: no reactor"]}`,
+		},
+		{
+			"unknown",
+			`{"name":"Sleep","reactor":{"tags":[{"_kind":"lable"},{"_kind":"faction","value":"east"}],"respond":{"when":{"signal":"lunch"},"then":{"_kind":"verb","verb":{"_verb":"attack"},"evaluator":{"_kind":"axis","axis":"hp"}}}}}`,
+			`{"valid":false,"fired":false,"problems":[` +
+				`"$.respond.then.evaluator.axis: unknown axis \"hp\"",` +
+				`"$.respond.when.signal: unknown signal \"lunch\"",` +
+				`"$.tags[0]: unknown _kind \"lable\"",` +
+				`"$.tags[1]: faction has no value \"east\""]}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			NewSkillController(new(mockSkillRepository), tagTypes).Mount(app)
+			req := httptest.NewRequest("POST", "/skills/validate", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(body))
+		})
+	}
+}
+
+func TestSkillController_ValidateSkill_Notation(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			"unknown",
+			"name: Sleep\nrespond: (when: (signal: lunch))\n",
+			fiber.StatusOK,
+			`{"valid":false,"fired":false,"problems":["$.respond.when.signal: unknown signal \"lunch\""]}`,
+		},
+		{
+			"syntax",
+			"name: Sleep\nrespond: (when: (signal: lunch)\n",
+			fiber.StatusBadRequest,
+			"",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			NewSkillController(new(mockSkillRepository), new(tagtype.Registry)).Mount(app)
+			req := httptest.NewRequest("POST", "/skills/validate", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/x-skill")
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+			if tt.want != "" {
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.want, string(body))
+			}
+		})
+	}
+}
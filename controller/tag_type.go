@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TagTypeController struct {
+	registry *tagtype.Registry
+}
+
+func NewTagTypeController(registry *tagtype.Registry) TagTypeController {
+	return TagTypeController{registry}
+}
+
+func (c TagTypeController) Mount(router fiber.Router) {
+	router.Get("/tag-types", c.GetTagTypes)
+}
+
+// GetTagTypes lists the builtin tag types, then the enumerated ones with
+// their values.
+func (c TagTypeController) GetTagTypes(fc *fiber.Ctx) error {
+	views := functional.MapSlice(func(name string) tagTypeView {
+		return tagTypeView{Name: name}
+	}, c.registry.Builtins())
+	views = append(views, functional.MapSlice(newTagTypeView, c.registry.Types())...)
+
+	return fc.JSON(views)
+}
+
+type tagTypeView storage.TagType
+
+func newTagTypeView(t storage.TagType) tagTypeView {
+	return tagTypeView(t)
+}
+
+func (v tagTypeView) MarshalJSON() ([]byte, error) {
+	m := map[string]any{
+		"name":    v.Name,
+		"builtin": v.Values == nil,
+	}
+	if v.Values != nil {
+		m["values"] = v.Values
+	}
+
+	return json.Marshal(m)
+}
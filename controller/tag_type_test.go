@@ -0,0 +1,37 @@
+package controller_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagTypeController_GetTagTypes(t *testing.T) {
+	tagTypes, err := tagtype.NewRegistry(
+		storage.TagType{Name: "faction", Values: []string{"north", "south"}},
+		storage.TagType{Name: "class", Values: []string{"mage"}},
+	)
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	NewTagTypeController(tagTypes).Mount(app)
+	req := httptest.NewRequest("GET", "/tag-types", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"name":"element","builtin":true},
+		{"name":"class","builtin":false,"values":["mage"]},
+		{"name":"faction","builtin":false,"values":["north","south"]}
+	]`, string(body))
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deleted characters and skills go to the trash, listed at /trash/<table>.
+// From there, POST /trash/<table>/:id/restore brings one back and
+// DELETE /trash/<table>/:id deletes it for good.
+
+func (c CharacterController) GetTrashedCharacters(fc *fiber.Ctx) error {
+	characters, err := c.repo.Trash()
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON(functional.MapSlice(c.view, characters))
+}
+
+func (c CharacterController) RestoreCharacter(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	character, err := c.repo.Restore(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, character.Revision)
+	return fc.JSON(c.view(*character))
+}
+
+func (c CharacterController) PurgeCharacter(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Purge(id); err != nil {
+		return storageError(err)
+	}
+
+	return fc.SendStatus(fiber.StatusNoContent)
+}
+
+func (c SkillController) GetTrashedSkills(fc *fiber.Ctx) error {
+	skills, err := c.repo.Trash()
+	if err != nil {
+		return storageError(err)
+	}
+
+	return fc.JSON(functional.MapSlice(newSkillView, skills))
+}
+
+func (c SkillController) RestoreSkill(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	skill, err := c.repo.Restore(id)
+	if err != nil {
+		return storageError(err)
+	}
+
+	setETag(fc, skill.Revision)
+	return fc.JSON((*skillView)(skill))
+}
+
+func (c SkillController) PurgeSkill(fc *fiber.Ctx) error {
+	id, err := fc.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	if err := c.repo.Purge(id); err != nil {
+		return storageError(err)
+	}
+
+	return fc.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package controller_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/farseeingnorthwest/battleground.go/controller"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharacterController_GetTrashedCharacters(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Trash").Return([]storage.Character{
+		{
+			ID:        1,
+			Name:      "Oda",
+			Revision:  2,
+			DeletedAt: &deletedAt,
+		},
+	}, nil)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("GET", "/trash/characters", nil)
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"deleted_at":"2026-10-01T00:00:00Z"`)
+}
+
+func TestCharacterController_RestoreCharacter(t *testing.T) {
+	r := new(mockCharacterRepository)
+	sr := new(mockSkillRepository)
+	r.On("Restore", 1).Return(&storage.Character{
+		ID:       1,
+		Name:     "Oda",
+		Revision: 2,
+	}, nil)
+	r.On("Restore", 2).Return((*storage.Character)(nil), storage.ErrNotFound)
+
+	app := fiber.New()
+	NewCharacterController(r, sr, CharacterRules{MaxSlots: 5}, new(tagtype.Registry)).Mount(app)
+	for _, tt := range []struct {
+		target string
+		status int
+	}{
+		{"/trash/characters/1/restore", fiber.StatusOK},
+		{"/trash/characters/2/restore", fiber.StatusNotFound},
+	} {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, nil)
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestSkillController_PurgeSkill(t *testing.T) {
+	r := new(mockSkillRepository)
+	r.On("Purge", 1).Return(nil)
+
+	app := fiber.New()
+	NewSkillController(r, new(tagtype.Registry)).Mount(app)
+	req := httptest.NewRequest("DELETE", "/trash/skills/1", nil)
+	resp, err := app.Test(req)
+
+	r.AssertExpectations(t)
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+)
+
+type LintCmd struct {
+	Dir string `type:"path" help:"Content directory to lint the skills of, instead of the database, which is then not connected to."`
+}
+
+// Run lints every skill, printing the findings, and fails if there are any
+// so that CI stops.
+func (cmd LintCmd) Run(globals *Globals) error {
+	skills, err := cmd.skills(globals)
+	if err != nil {
+		return err
+	}
+
+	var findings int
+	for _, skill := range skills {
+		doc, err := skill.Reactor.Document()
+		if err != nil {
+			return fmt.Errorf("skills/%d: %w", skill.ID, err)
+		}
+		for _, finding := range reactor.Lint(doc) {
+			fmt.Printf("skills/%d %s\n", skill.ID, finding)
+			findings++
+		}
+	}
+	if findings > 0 {
+		return fmt.Errorf("%d finding(s)", findings)
+	}
+
+	return nil
+}
+
+// skills loads the skills to lint, registering the tag types first. The
+// skills of a content directory are loaded without the database, knowing
+// only the tag types of --tag-types.
+func (cmd LintCmd) skills(globals *Globals) ([]storage.Skill, error) {
+	if cmd.Dir != "" {
+		var types []storage.TagType
+		if globals.TagTypes != "" {
+			var err error
+			if types, err = tagtype.Load(globals.TagTypes); err != nil {
+				return nil, err
+			}
+		}
+		tagTypes, err := tagtype.NewRegistry(types...)
+		if err != nil {
+			return nil, err
+		}
+		tagTypes.Register()
+
+		return storage.NewFileSkillRepository(storage.NewDirectory(cmd.Dir)).FindEx()
+	}
+
+	db, err := globals.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tagTypes, err := tagtype.Open(globals.TagTypes, storage.NewTagTypeRepository(db))
+	if err != nil {
+		return nil, err
+	}
+	tagTypes.Register()
+
+	return storage.NewSkillRepository(db).FindEx()
+}
@@ -2,13 +2,12 @@ package main
 
 import (
 	"context"
-
-	"github.com/farseeingnorthwest/playground/battlefield/v2"
-	"github.com/farseeingnorthwest/playground/battlefield/v2/examples"
+	"errors"
 
 	"github.com/alecthomas/kong"
 	"github.com/farseeingnorthwest/battleground.go/controller"
 	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -17,31 +16,73 @@ import (
 	"go.uber.org/fx"
 )
 
+type Globals struct {
+	DSN      string `env:"DATABASE_URL" help:"Database to connect to, needed by every command but lint --dir."`
+	TagTypes string `type:"existingfile" env:"TAG_TYPES" help:"YAML file of enumerated tag types, on top of the tag_types table."`
+}
+
+// connect connects to the database, which has to be given.
+func (g *Globals) connect() (*sqlx.DB, error) {
+	if g.DSN == "" {
+		return nil, errors.New("DATABASE_URL is required")
+	}
+
+	return sqlx.Connect("postgres", g.DSN)
+}
+
 func main() {
 	var cli struct {
-		Debug  bool
-		DSN    string `env:"DATABASE_URL" required:""`
-		Addr   string `default:":3000"`
-		Static string
+		Globals
+
+		Serve  ServeCmd  `cmd:"" default:"withargs" help:"Serve the API."`
+		Sync   SyncCmd   `cmd:"" help:"Reconcile a content directory with the database."`
+		Lint   LintCmd   `cmd:"" help:"Report authoring mistakes in skills."`
+		Rehash RehashCmd `cmd:"" help:"Hash the reactors of skills stored before they were hashed, and report identical skills."`
+	}
+	ctx := kong.Parse(&cli)
+	ctx.FatalIfErrorf(ctx.Run(&cli.Globals))
+}
+
+type ServeCmd struct {
+	Debug    bool
+	Addr     string `default:":3000"`
+	Static   string
+	MaxSlots int    `default:"5" help:"Number of skill slots of a character."`
+	Rules    string `type:"existingfile" help:"YAML file of character stat rules and budget."`
+}
+
+func (cmd ServeCmd) Run(globals *Globals) error {
+	rules := controller.DefaultCharacterRules()
+	if cmd.Rules != "" {
+		var err error
+		if rules, err = controller.LoadCharacterRules(cmd.Rules); err != nil {
+			return err
+		}
 	}
-	kong.Parse(&cli)
+	rules.MaxSlots = cmd.MaxSlots
 
 	fx.New(
 		storage.Module,
+		tagtype.Module,
 		controller.Module,
 		fx.Supply(
 			fx.Annotate(
-				cli.Addr,
+				cmd.Addr,
 				fx.ResultTags(`name:"addr"`),
 			),
 			fx.Annotate(
-				cli.Debug,
+				cmd.Debug,
 				fx.ResultTags(`name:"debug"`),
 			),
 			fx.Annotate(
-				cli.Static,
+				cmd.Static,
 				fx.ResultTags(`name:"static"`),
 			),
+			fx.Annotate(
+				globals.TagTypes,
+				fx.ResultTags(`name:"tag-types"`),
+			),
+			rules,
 		),
 		fx.Provide(
 			func(r *storage.CharacterRepository) controller.CharacterRepository {
@@ -50,8 +91,14 @@ func main() {
 			func(r *storage.SkillRepository) controller.SkillRepository {
 				return r
 			},
+			func(r *storage.ItemRepository) controller.ItemRepository {
+				return r
+			},
+			func(r *storage.SkillTemplateRepository) controller.SkillTemplateRepository {
+				return r
+			},
 			func() *sqlx.DB {
-				db, err := sqlx.Connect("postgres", cli.DSN)
+				db, err := globals.connect()
 				if err != nil {
 					panic(err)
 				}
@@ -61,6 +108,8 @@ func main() {
 		),
 		fx.Invoke(func(app *fiber.App) {}),
 	).Run()
+
+	return nil
 }
 
 func NewFiberApp(params FiberAppParams, lc fx.Lifecycle) *fiber.App {
@@ -78,7 +127,7 @@ func NewFiberApp(params FiberAppParams, lc fx.Lifecycle) *fiber.App {
 
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
-			battlefield.RegisterTagType("element", examples.Element(0))
+			params.TagTypes.Register()
 			go func() {
 				if err := app.Listen(params.Addr); err != nil {
 					log.Error(err)
@@ -101,4 +150,5 @@ type FiberAppParams struct {
 	Addr        string                  `name:"addr"`
 	Debug       bool                    `name:"debug"`
 	Static      string                  `name:"static"`
+	TagTypes    *tagtype.Registry
 }
@@ -0,0 +1,95 @@
+package reactor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// Canonical writes a decoded document so that documents meaning the same are
+// written the same: members in key order, no blanks, and numbers normalized,
+// integers without fractions or exponents and others in their shortest form.
+func Canonical(doc any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(normalize(doc)); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Unmarshal decodes a JSON document, keeping numbers as json.Number so that
+// they compare and print exactly.
+func Unmarshal(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Hash is the SHA-256 of the canonical form of a decoded document, in hex.
+func Hash(doc any) (string, error) {
+	b, err := Canonical(doc)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, u := range v {
+			m[k] = normalize(u)
+		}
+		return m
+
+	case []any:
+		a := make([]any, len(v))
+		for i, u := range v {
+			a[i] = normalize(u)
+		}
+		return a
+
+	case json.Number:
+		return normalizeNumber(string(v))
+
+	case float64:
+		return normalizeNumber(strconv.FormatFloat(v, 'g', -1, 64))
+
+	case int:
+		return json.Number(strconv.Itoa(v))
+
+	default:
+		return v
+	}
+}
+
+func normalizeNumber(s string) json.Number {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return json.Number(strconv.FormatInt(i, 10))
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return json.Number(s)
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return json.Number(strconv.FormatInt(int64(f), 10))
+	}
+
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
@@ -0,0 +1,48 @@
+package reactor_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	for _, tt := range []struct {
+		doc, canonical string
+	}{
+		{`{"b": 1, "a": [true, null, "x"]}`, `{"a":[true,null,"x"],"b":1}`},
+		{`{"a": 1.0, "b": 1e2, "c": -0, "d": 0.50, "e": 2.5E-1}`, `{"a":1,"b":100,"c":0,"d":0.5,"e":0.25}`},
+		{`{"a": 9007199254740993}`, `{"a":9007199254740993}`},
+		{`{"a": "<&>"}`, `{"a":"<&>"}`},
+	} {
+		t.Run(tt.doc, func(t *testing.T) {
+			doc, err := Unmarshal([]byte(tt.doc))
+			assert.NoError(t, err)
+
+			b, err := Canonical(doc)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.canonical, string(b))
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	a, err := Unmarshal([]byte(`{"label": "Normal Attack", "multiplier": 100}`))
+	assert.NoError(t, err)
+	b, err := Unmarshal([]byte(`{"multiplier": 1e2, "label": "Normal Attack"}`))
+	assert.NoError(t, err)
+	c, err := Unmarshal([]byte(`{"multiplier": 101, "label": "Normal Attack"}`))
+	assert.NoError(t, err)
+
+	hashA, err := Hash(a)
+	assert.NoError(t, err)
+	assert.Len(t, hashA, 64)
+	hashB, err := Hash(b)
+	assert.NoError(t, err)
+	hashC, err := Hash(c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, hashA, hashB)
+	assert.NotEqual(t, hashA, hashC)
+}
@@ -0,0 +1,246 @@
+package reactor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Comparison is the difference between two reactors in the terms of
+// reactors rather than of JSON.
+type Comparison struct {
+	// Tags are the tags added to or removed from the tags of a reactor,
+	// wherever they are in the list.
+	Tags []Change `json:"tags"`
+
+	// Triggers are the when members of responders and lifecycles which
+	// changed, as a whole.
+	Triggers []Change `json:"triggers"`
+
+	// Values are the numbers which changed elsewhere.
+	Values []Change `json:"values"`
+
+	// Others are the rest of the changes.
+	Others []Change `json:"others"`
+}
+
+type Op string
+
+const (
+	Added   Op = "added"
+	Removed Op = "removed"
+	Changed Op = "changed"
+)
+
+// Change is a difference between two JSON documents at a path such as
+// $.respond.then.do[0].verb.
+type Change struct {
+	Op   Op     `json:"op"`
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Compare compares two decoded reactors, and the reactors their buffs
+// carry. Changes are listed in path order within each kind.
+func Compare(a, b any) Comparison {
+	c := Comparison{
+		Tags:     []Change{},
+		Triggers: []Change{},
+		Values:   []Change{},
+		Others:   []Change{},
+	}
+
+	tagsA, tagsB := members(a, "tags"), members(b, "tags")
+	for _, path := range union(tagsA, tagsB) {
+		c.Tags = append(c.Tags, compareTags(path, tagsA[path], tagsB[path])...)
+	}
+
+	whenA, whenB := members(a, "when"), members(b, "when")
+	for _, path := range union(whenA, whenB) {
+		u, inA := whenA[path]
+		v, inB := whenB[path]
+		switch {
+		case !inB:
+			c.Triggers = append(c.Triggers, Change{Op: Removed, Path: path, Old: u})
+		case !inA:
+			c.Triggers = append(c.Triggers, Change{Op: Added, Path: path, New: v})
+		case key(u) != key(v):
+			c.Triggers = append(c.Triggers, Change{Op: Changed, Path: path, Old: u, New: v})
+		}
+	}
+
+	var changes []Change
+	diff("$", without(a, "tags", "when"), without(b, "tags", "when"), &changes)
+	for _, change := range changes {
+		_, oldNumber := change.Old.(json.Number)
+		_, newNumber := change.New.(json.Number)
+		if change.Op == Changed && oldNumber && newNumber {
+			c.Values = append(c.Values, change)
+		} else {
+			c.Others = append(c.Others, change)
+		}
+	}
+
+	return c
+}
+
+// diff compares two decoded documents member by member and element by
+// element, in path order.
+func diff(path string, a, b any, changes *[]Change) {
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			keys := make([]string, 0, len(a)+len(b))
+			for k := range a {
+				keys = append(keys, k)
+			}
+			for k := range b {
+				if _, ok := a[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				u, inA := a[k]
+				v, inB := b[k]
+				p := member(path, k)
+				switch {
+				case !inB:
+					*changes = append(*changes, Change{Op: Removed, Path: p, Old: u})
+				case !inA:
+					*changes = append(*changes, Change{Op: Added, Path: p, New: v})
+				default:
+					diff(p, u, v, changes)
+				}
+			}
+			return
+		}
+
+	case []any:
+		if b, ok := b.([]any); ok {
+			for i := 0; i < len(a) || i < len(b); i++ {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(b):
+					*changes = append(*changes, Change{Op: Removed, Path: p, Old: a[i]})
+				case i >= len(a):
+					*changes = append(*changes, Change{Op: Added, Path: p, New: b[i]})
+				default:
+					diff(p, a[i], b[i], changes)
+				}
+			}
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Op: Changed, Path: path, Old: a, New: b})
+	}
+}
+
+func member(path, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Sprintf("%s[%q]", path, key)
+		}
+	}
+
+	return path + "." + key
+}
+
+// compareTags lists the tags of b missing from a as added, and those of a
+// missing from b as removed, counting duplicates.
+func compareTags(path string, a, b any) []Change {
+	count := make(map[string]int)
+	for _, tag := range elements(b) {
+		count[key(tag)]++
+	}
+
+	var changes []Change
+	for _, tag := range elements(a) {
+		if k := key(tag); count[k] > 0 {
+			count[k]--
+		} else {
+			changes = append(changes, Change{Op: Removed, Path: path, Old: tag})
+		}
+	}
+	for _, tag := range elements(b) {
+		if k := key(tag); count[k] > 0 {
+			count[k]--
+			changes = append(changes, Change{Op: Added, Path: path, New: tag})
+		}
+	}
+
+	return changes
+}
+
+// members finds the members of the given name throughout a document, by
+// path.
+func members(doc any, name string) map[string]any {
+	found := make(map[string]any)
+	Walk(doc, func(path string, v any) {
+		if m, ok := v.(map[string]any); ok {
+			if u, ok := m[name]; ok {
+				found[member(path, name)] = u
+			}
+		}
+	})
+
+	return found
+}
+
+// without is a copy of a document leaving out the members of the given
+// names throughout.
+func without(v any, names ...string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+	members:
+		for k, u := range v {
+			for _, name := range names {
+				if k == name {
+					continue members
+				}
+			}
+			m[k] = without(u, names...)
+		}
+		return m
+
+	case []any:
+		a := make([]any, len(v))
+		for i, u := range v {
+			a[i] = without(u, names...)
+		}
+		return a
+
+	default:
+		return v
+	}
+}
+
+func union(a, b map[string]any) []string {
+	keys := sortedKeys(a)
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func elements(v any) []any {
+	a, _ := v.([]any)
+	return a
+}
+
+// key is the canonical form of a value, which tells values meaning the same
+// apart from different ones.
+func key(v any) string {
+	b, _ := Canonical(v)
+	return string(b)
+}
@@ -0,0 +1,92 @@
+package reactor_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	a, err := Unmarshal([]byte(`{
+  "tags": [{"_kind": "exclusion_group", "index": 0}, {"_kind": "label", "text": "Sleep"}],
+  "capacity": {"count": 1, "when": [{"signal": "round_end"}]},
+  "respond": {
+    "when": {"signal": "launch"},
+    "then": {"_kind": "select", "selector": {"_kind": "front", "count": 1}, "do": {"_kind": "verb", "verb": {"_verb": "attack"}}}
+  }
+}`))
+	assert.NoError(t, err)
+	b, err := Unmarshal([]byte(`{
+  "tags": [{"_kind": "label", "text": "Sleep"}, {"_kind": "priority", "index": 10}],
+  "capacity": {"count": 2, "when": [{"signal": "round_start"}]},
+  "respond": {
+    "when": {"signal": "launch"},
+    "then": {"_kind": "select", "selector": {"_kind": "front", "count": 3}, "do": {"_kind": "verb", "verb": {"_verb": "heal"}}}
+  }
+}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, Comparison{
+		Tags: []Change{
+			{Op: Removed, Path: "$.tags", Old: map[string]any{"_kind": "exclusion_group", "index": json.Number("0")}},
+			{Op: Added, Path: "$.tags", New: map[string]any{"_kind": "priority", "index": json.Number("10")}},
+		},
+		Triggers: []Change{
+			{
+				Op:   Changed,
+				Path: "$.capacity.when",
+				Old:  []any{map[string]any{"signal": "round_end"}},
+				New:  []any{map[string]any{"signal": "round_start"}},
+			},
+		},
+		Values: []Change{
+			{Op: Changed, Path: "$.capacity.count", Old: json.Number("1"), New: json.Number("2")},
+			{Op: Changed, Path: "$.respond.then.selector.count", Old: json.Number("1"), New: json.Number("3")},
+		},
+		Others: []Change{
+			{Op: Changed, Path: "$.respond.then.do.verb._verb", Old: "attack", New: "heal"},
+		},
+	}, Compare(a, b))
+
+	assert.Equal(t, Comparison{Tags: []Change{}, Triggers: []Change{}, Values: []Change{}, Others: []Change{}}, Compare(a, a))
+
+	c, err := Unmarshal([]byte(`{
+  "tags": [{"_kind": "label", "text": "Sleep"}, {"index": 0.0, "_kind": "exclusion_group"}],
+  "capacity": {"count": 1, "when": [{"signal": "round_end"}]},
+  "respond": {
+    "when": {"signal": "launch"},
+    "then": {"_kind": "select", "selector": {"_kind": "front", "count": 1}, "do": {"_kind": "verb", "verb": {"_verb": "attack"}}}
+  }
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, Comparison{Tags: []Change{}, Triggers: []Change{}, Values: []Change{}, Others: []Change{}}, Compare(a, c))
+}
+
+func TestCompare_Others(t *testing.T) {
+	for _, tt := range []struct {
+		a, b   string
+		others []Change
+	}{
+		{
+			`{"capacity":{"count":1}}`,
+			`{"respond":{}}`,
+			[]Change{
+				{Op: Removed, Path: "$.capacity", Old: map[string]any{"count": json.Number("1")}},
+				{Op: Added, Path: "$.respond", New: map[string]any{}},
+			},
+		},
+		{`{"a b":[1,2]}`, `{"a b":[1]}`, []Change{{Op: Removed, Path: `$["a b"][1]`, Old: json.Number("2")}}},
+		{`{"a":[1]}`, `{"a":{"0":1}}`, []Change{{Op: Changed, Path: "$.a", Old: []any{json.Number("1")}, New: map[string]any{"0": json.Number("1")}}}},
+	} {
+		t.Run(tt.a, func(t *testing.T) {
+			a, err := Unmarshal([]byte(tt.a))
+			assert.NoError(t, err)
+			b, err := Unmarshal([]byte(tt.b))
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.others, Compare(a, b).Others)
+		})
+	}
+}
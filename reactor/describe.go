@@ -0,0 +1,535 @@
+package reactor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Languages are the languages descriptions are written in, the default one
+// first.
+var Languages = []string{"en", "ja"}
+
+// language holds the templates a description is written with. Verbs are
+// given their targets as %[1]s and their amount as %[2]s.
+type language struct {
+	signals    map[string]string
+	axes       map[string]string
+	verbs      map[string]string
+	bareVerbs  map[string]string
+	verbNouns  map[string]string
+	predicates map[string]string
+
+	sentence   string // %[1]s signal, %[2]s action
+	sentenceIf string // %[3]s condition
+	capacity   string // %[1]v count, %[2]s triggers
+	lasts      string // %[1]v count
+	triggerIf  string // %[1]s signal, %[2]s condition
+	verbIs     string
+	not        string
+	and, or    string
+	then       string
+	list       string
+	repeat     string // %[1]v count, %[2]s action
+	maybe      string
+	each       string // %[1]s targets, %[2]s action
+	percent    string // %[1]s axis, %[2]v percentage
+	percentOf  string // %[1]s evaluator, %[2]v percentage
+	plus       string // %[1]s evaluator, %[2]v addend
+	values     map[string]string
+	value      string // of an unknown evaluator
+	end, space string
+	nothing    string
+	buff       string
+	target     string // without a selector
+	aside      string // after a preference, before an amount
+	targets    func(t targets) string
+}
+
+// targets are what a pipeline of selectors picks: count warriors, all of
+// them if empty, of a side if any, passing filters.
+type targets struct {
+	count      string
+	random     bool
+	ally       *bool
+	filters    []string
+	preference string
+}
+
+var languages = map[string]*language{
+	"en": {
+		signals: map[string]string{
+			"battle_start": "at battle start",
+			"launch":       "on launch",
+			"lifecycle":    "on a lifecycle change",
+			"post_action":  "after an action",
+			"pre_action":   "before an action",
+			"round_end":    "at round end",
+			"round_start":  "at round start",
+		},
+		axes: map[string]string{
+			"critical_loss": "critical damage",
+			"critical_odds": "critical rate",
+			"damage":        "damage",
+			"defense":       "defense",
+			"health":        "health",
+			"speed":         "speed",
+		},
+		verbs: map[string]string{
+			"attack": "attack %[1]s for %[2]s",
+			"buff":   "give %[1]s %[2]s",
+			"heal":   "heal %[1]s for %[2]s",
+			"purge":  "purge %[1]s",
+		},
+		bareVerbs: map[string]string{
+			"attack": "attack %[1]s",
+			"buff":   "buff %[1]s",
+			"heal":   "heal %[1]s",
+			"purge":  "purge %[1]s",
+		},
+		verbNouns: map[string]string{
+			"attack": "an attack",
+			"buff":   "a buff",
+			"heal":   "a heal",
+			"purge":  "a purge",
+		},
+		predicates: map[string]string{
+			"critical_strike":   "the strike is critical",
+			"current_is_source": "the holder acts",
+			"current_is_target": "the holder is targeted",
+			"false":             "never",
+			"true":              "always",
+		},
+		sentence:   "%[1]s: %[2]s",
+		sentenceIf: "%[1]s, if %[3]s: %[2]s",
+		capacity:   "lasts %[1]v (counting down %[2]s)",
+		lasts:      "lasts %[1]v",
+		triggerIf:  "%[1]s if %[2]s",
+		verbIs:     "the action is %s",
+		not:        "not %s",
+		and:        " and ",
+		or:         " or ",
+		then:       ", then ",
+		list:       ", or ",
+		repeat:     "%[2]s %[1]v times",
+		maybe:      "possibly %s",
+		each:       "for %[1]s: %[2]s",
+		percent:    "%[2]v%% %[1]s",
+		percentOf:  "%[2]v%% of %[1]s",
+		plus:       "%[1]s + %[2]v",
+		value:      "an amount",
+		end:        ".",
+		space:      " ",
+		nothing:    "nothing",
+		buff:       "a buff",
+		target:     "the target",
+		aside:      ",",
+		values: map[string]string{
+			"buff_count": "the number of buffs",
+			"custom":     "a custom amount",
+		},
+		targets: func(t targets) string {
+			singular, plural := "warrior", "warriors"
+			if t.ally != nil {
+				if *t.ally {
+					singular, plural = "ally", "allies"
+				} else {
+					singular, plural = "enemy", "enemies"
+				}
+			}
+
+			var s string
+			switch {
+			case t.count == "":
+				s = "all " + plural
+			case t.count == "1":
+				s = "1 " + singular
+			default:
+				s = t.count + " " + plural
+			}
+			if t.random && t.count != "" {
+				s = strings.Replace(s, " ", " random ", 1)
+			}
+			if len(t.filters) > 0 {
+				s += " with " + strings.Join(t.filters, " and ")
+			}
+			if t.preference != "" {
+				s += ", preferring " + t.preference
+			}
+
+			return s
+		},
+	},
+	"ja": {
+		signals: map[string]string{
+			"battle_start": "戦闘開始時",
+			"launch":       "発動時",
+			"lifecycle":    "効果の変化時",
+			"post_action":  "行動後",
+			"pre_action":   "行動前",
+			"round_end":    "ラウンド終了時",
+			"round_start":  "ラウンド開始時",
+		},
+		axes: map[string]string{
+			"critical_loss": "会心ダメージ",
+			"critical_odds": "会心率",
+			"damage":        "攻撃力",
+			"defense":       "防御力",
+			"health":        "HP",
+			"speed":         "速度",
+		},
+		verbs: map[string]string{
+			"attack": "%[1]sに%[2]sの攻撃",
+			"buff":   "%[1]sに%[2]sを付与",
+			"heal":   "%[1]sを%[2]s回復",
+			"purge":  "%[1]sの効果を解除",
+		},
+		bareVerbs: map[string]string{
+			"attack": "%[1]sに攻撃",
+			"buff":   "%[1]sに効果を付与",
+			"heal":   "%[1]sを回復",
+			"purge":  "%[1]sの効果を解除",
+		},
+		verbNouns: map[string]string{
+			"attack": "攻撃",
+			"buff":   "付与",
+			"heal":   "回復",
+			"purge":  "解除",
+		},
+		predicates: map[string]string{
+			"critical_strike":   "会心の場合",
+			"current_is_source": "自身の行動の場合",
+			"current_is_target": "自身が対象の場合",
+			"false":             "常に無効",
+			"true":              "常に",
+		},
+		sentence:   "%[1]s：%[2]s",
+		sentenceIf: "%[1]s、%[3]s：%[2]s",
+		capacity:   "%[2]sにカウントが減り、%[1]v回で消滅",
+		lasts:      "%[1]v回で消滅",
+		triggerIf:  "%[1]s（%[2]s）",
+		verbIs:     "行動が%sの場合",
+		not:        "%sでない",
+		and:        "かつ",
+		or:         "または",
+		then:       "、その後",
+		list:       "または",
+		repeat:     "%[2]sを%[1]v回",
+		maybe:      "確率で%s",
+		each:       "%[1]sに対して：%[2]s",
+		percent:    "%[1]s%[2]v%%",
+		percentOf:  "%[1]sの%[2]v%%",
+		plus:       "%[1]s+%[2]v",
+		value:      "値",
+		end:        "。",
+		space:      "",
+		nothing:    "何もしない",
+		buff:       "効果",
+		target:     "対象",
+		aside:      "",
+		values: map[string]string{
+			"buff_count": "効果の数",
+			"custom":     "特殊な値",
+		},
+		targets: func(t targets) string {
+			var s string
+			if len(t.filters) > 0 {
+				s = strings.Join(t.filters, "かつ") + "の"
+			}
+			switch {
+			case t.ally == nil:
+				s += "キャラ"
+			case *t.ally:
+				s += "味方"
+			default:
+				s += "敵"
+			}
+			switch {
+			case t.count == "":
+				s += "全員"
+			case t.random:
+				s += "からランダムに" + t.count + "体"
+			default:
+				s += t.count + "体"
+			}
+			if t.preference != "" {
+				s += "（" + t.preference + "優先）"
+			}
+
+			return s
+		},
+	},
+}
+
+// Describe writes a decoded reactor out in words, in one of Languages: what
+// it does in response to what, then how long it lasts. Parts of the reactor
+// the templates do not cover are named by their _kind.
+func Describe(doc any, lang string) (string, error) {
+	l, ok := languages[lang]
+	if !ok {
+		return "", fmt.Errorf("unknown language %q", lang)
+	}
+
+	m, _ := doc.(map[string]any)
+	var sentences []string
+	if respond, ok := m["respond"].(map[string]any); ok {
+		when, _ := respond["when"].(map[string]any)
+		signal := l.signal(when["signal"])
+		action := l.action(respond["then"], nil)
+		if cond := l.conditions(when["if"]); cond != "" {
+			sentences = append(sentences, fmt.Sprintf(l.sentenceIf, signal, action, cond))
+		} else {
+			sentences = append(sentences, fmt.Sprintf(l.sentence, signal, action))
+		}
+	}
+	if capacity, ok := m["capacity"].(map[string]any); ok {
+		when, _ := capacity["when"].([]any)
+		triggers := make([]string, 0, len(when))
+		for _, trigger := range when {
+			trigger, _ := trigger.(map[string]any)
+			signal := l.signal(trigger["signal"])
+			if cond := l.conditions(trigger["if"]); cond != "" {
+				signal = fmt.Sprintf(l.triggerIf, signal, cond)
+			}
+			triggers = append(triggers, signal)
+		}
+		if len(triggers) > 0 {
+			sentences = append(sentences, fmt.Sprintf(l.capacity, capacity["count"], strings.Join(triggers, l.list)))
+		} else {
+			sentences = append(sentences, fmt.Sprintf(l.lasts, capacity["count"]))
+		}
+	}
+
+	for i, s := range sentences {
+		sentences[i] = capitalize(s) + l.end
+	}
+
+	return strings.Join(sentences, l.space), nil
+}
+
+func (l *language) signal(v any) string {
+	s, _ := v.(string)
+	if phrase, ok := l.signals[s]; ok {
+		return phrase
+	}
+
+	return s
+}
+
+// conditions describes the conditions of a trigger, which all must hold.
+func (l *language) conditions(v any) string {
+	conds, _ := v.([]any)
+	phrases := make([]string, len(conds))
+	for i, cond := range conds {
+		phrases[i] = l.condition(cond)
+	}
+
+	return strings.Join(phrases, l.and)
+}
+
+func (l *language) condition(v any) string {
+	m, _ := v.(map[string]any)
+	kind, _ := m["_kind"].(string)
+	switch kind {
+	case "verb":
+		verb, _ := m["verb"].(string)
+		if noun, ok := l.verbNouns[verb]; ok {
+			return fmt.Sprintf(l.verbIs, noun)
+		}
+		return fmt.Sprintf(l.verbIs, verb)
+	case "and":
+		return l.conditions(m["conditions"])
+	case "or":
+		conds, _ := m["conditions"].([]any)
+		phrases := make([]string, len(conds))
+		for i, cond := range conds {
+			phrases[i] = l.condition(cond)
+		}
+		return strings.Join(phrases, l.or)
+	case "not":
+		return fmt.Sprintf(l.not, l.condition(m["condition"]))
+	}
+	if phrase, ok := l.predicates[kind]; ok {
+		return phrase
+	}
+
+	return kind
+}
+
+// action describes an action taken on the targets selected around it, if
+// any.
+func (l *language) action(v any, t *targets) string {
+	m, _ := v.(map[string]any)
+	kind, _ := m["_kind"].(string)
+	switch kind {
+	case "sequence":
+		do, _ := m["do"].([]any)
+		if len(do) == 0 {
+			return l.nothing
+		}
+		phrases := make([]string, len(do))
+		for i, action := range do {
+			phrases[i] = l.action(action, t)
+		}
+		return strings.Join(phrases, l.then)
+	case "select":
+		selected := l.selector(m["selector"])
+		if do, _ := m["do"].(map[string]any); do["_kind"] == "verb" {
+			return l.verb(do, &selected)
+		}
+		return fmt.Sprintf(l.each, l.targets(selected), l.action(m["do"], nil))
+	case "verb":
+		return l.verb(m, t)
+	case "repeat":
+		return fmt.Sprintf(l.repeat, m["count"], l.action(m["do"], t))
+	case "probability":
+		return fmt.Sprintf(l.maybe, l.action(m["do"], t))
+	}
+
+	return kind
+}
+
+func (l *language) verb(m map[string]any, t *targets) string {
+	verb, _ := m["verb"].(map[string]any)
+	name, _ := verb["_verb"].(string)
+
+	var amount string
+	if name == "buff" {
+		amount = l.buff
+		if r, ok := verb["reactor"].(map[string]any); ok {
+			if label := labelOf(r["tags"]); label != "" {
+				amount = label
+			}
+		}
+	} else if m["evaluator"] != nil {
+		amount = l.evaluator(m["evaluator"])
+	}
+
+	phrase := l.target
+	if t != nil {
+		phrase = l.targets(*t)
+	}
+	if amount != "" {
+		if t != nil && t.preference != "" {
+			phrase += l.aside
+		}
+		if template, ok := l.verbs[name]; ok {
+			return fmt.Sprintf(template, phrase, amount)
+		}
+	}
+	if template, ok := l.bareVerbs[name]; ok {
+		return fmt.Sprintf(template, phrase)
+	}
+
+	return name
+}
+
+// evaluator tells the amount an evaluator gives. Multipliers and adders are
+// told through the evaluators they wrap, and a multiplier of an axis reads
+// as a percentage of it.
+func (l *language) evaluator(v any) string {
+	m, _ := v.(map[string]any)
+	kind, _ := m["_kind"].(string)
+	switch kind {
+	case "axis":
+		return fmt.Sprintf(l.percent, l.axis(m["axis"]), 100)
+	case "constant":
+		return fmt.Sprint(m["value"])
+	case "multiplier":
+		inner, _ := m["evaluator"].(map[string]any)
+		if inner == nil {
+			return fmt.Sprintf("%v%%", m["multiplier"])
+		}
+		if inner["_kind"] == "axis" {
+			return fmt.Sprintf(l.percent, l.axis(inner["axis"]), m["multiplier"])
+		}
+		return fmt.Sprintf(l.percentOf, l.evaluator(inner), m["multiplier"])
+	case "adder":
+		if m["evaluator"] == nil {
+			return fmt.Sprint(m["addend"])
+		}
+		if m["addend"] == nil {
+			return l.evaluator(m["evaluator"])
+		}
+		return fmt.Sprintf(l.plus, l.evaluator(m["evaluator"]), m["addend"])
+	}
+	if value, ok := l.values[kind]; ok {
+		return value
+	}
+
+	return l.value
+}
+
+func (l *language) axis(v any) string {
+	s, _ := v.(string)
+	if name, ok := l.axes[s]; ok {
+		return name
+	}
+
+	return s
+}
+
+// selector tells the warriors a selector picks. Selectors other than
+// pipelines pick from everyone.
+func (l *language) selector(v any) targets {
+	m, _ := v.(map[string]any)
+	selectors := []any{m}
+	if m["_kind"] == "pipeline" {
+		selectors, _ = m["selectors"].([]any)
+	}
+
+	var t targets
+	for _, selector := range selectors {
+		selector, _ := selector.(map[string]any)
+		switch selector["_kind"] {
+		case "side":
+			if ally, ok := selector["side"].(bool); ok {
+				t.ally = &ally
+			}
+		case "front":
+			t.count = fmt.Sprint(selector["count"])
+		case "shuffle":
+			t.random = true
+			if preference, ok := selector["preference"].(map[string]any); ok {
+				t.preference = labelOf([]any{preference})
+			}
+		case "water_level":
+			t.filters = append(t.filters, fmt.Sprintf("%s %v %v", l.evaluatorAxis(selector["evaluator"]), selector["comparator"], selector["value"]))
+		}
+	}
+
+	return t
+}
+
+// evaluatorAxis names what an evaluator reads, for filters.
+func (l *language) evaluatorAxis(v any) string {
+	m, _ := v.(map[string]any)
+	if m["_kind"] == "axis" {
+		return l.axis(m["axis"])
+	}
+
+	return l.evaluator(v)
+}
+
+// labelOf is the text of the first label among tags.
+func labelOf(v any) string {
+	tags, _ := v.([]any)
+	for _, tag := range tags {
+		if tag, ok := tag.(map[string]any); ok && tag["_kind"] == "label" {
+			text, _ := tag["text"].(string)
+			return text
+		}
+	}
+
+	return ""
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
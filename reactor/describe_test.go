@@ -0,0 +1,91 @@
+package reactor_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDescribe(t *testing.T) {
+	b, err := os.ReadFile("../storage/fixtures/skills.yml")
+	assert.NoError(t, err)
+
+	var skills []struct {
+		Name    string `yaml:"name"`
+		Reactor string `yaml:"reactor"`
+	}
+	assert.NoError(t, yaml.Unmarshal(b, &skills))
+
+	descriptions := map[string]map[string]string{
+		"Normal Attack": {
+			"en": "On launch: attack 1 random enemy with health > 0, preferring Taunt, for 100% damage.",
+			"ja": "発動時：HP > 0の敵からランダムに1体（Taunt優先）に攻撃力100%の攻撃。",
+		},
+		"Sleep": {
+			"en": "On launch: nothing. Lasts 1 (counting down at round end, or after an action if the action is an attack and the holder is targeted).",
+			"ja": "発動時：何もしない。ラウンド終了時または行動後（行動が攻撃の場合かつ自身が対象の場合）にカウントが減り、1回で消滅。",
+		},
+	}
+	for _, skill := range skills {
+		doc, err := Unmarshal([]byte(skill.Reactor))
+		assert.NoError(t, err)
+
+		for _, lang := range Languages {
+			t.Run(skill.Name+"/"+lang, func(t *testing.T) {
+				description, err := Describe(doc, lang)
+				assert.NoError(t, err)
+				assert.Equal(t, descriptions[skill.Name][lang], description)
+			})
+		}
+	}
+
+	_, err = Describe(nil, "fr")
+	assert.Error(t, err)
+}
+
+func TestDescribe_Evaluators(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		evaluator string
+		en, ja    string
+	}{
+		{
+			"multiplier",
+			`{"_kind":"multiplier","multiplier":150,"evaluator":{"_kind":"axis","axis":"damage"}}`,
+			"On launch: attack the target for 150% damage.",
+			"発動時：対象に攻撃力150%の攻撃。",
+		},
+		{
+			"nested multiplier",
+			`{"_kind":"multiplier","multiplier":200,"evaluator":{"_kind":"multiplier","multiplier":150,"evaluator":{"_kind":"axis","axis":"damage"}}}`,
+			"On launch: attack the target for 200% of 150% damage.",
+			"発動時：対象に攻撃力150%の200%の攻撃。",
+		},
+		{
+			"adder",
+			`{"_kind":"adder","addend":10,"evaluator":{"_kind":"multiplier","multiplier":50,"evaluator":{"_kind":"axis","axis":"defense"}}}`,
+			"On launch: attack the target for 50% defense + 10.",
+			"発動時：対象に防御力50%+10の攻撃。",
+		},
+		{
+			"unknown",
+			`{"_kind":"gravity"}`,
+			"On launch: attack the target for an amount.",
+			"発動時：対象に値の攻撃。",
+		},
+	} {
+		doc, err := Unmarshal([]byte(`{"respond":{"when":{"signal":"launch"},"then":{"_kind":"verb","verb":{"_verb":"attack"},"evaluator":` + tt.evaluator + `}}}`))
+		assert.NoError(t, err)
+
+		for lang, want := range map[string]string{"en": tt.en, "ja": tt.ja} {
+			t.Run(tt.name+"/"+lang, func(t *testing.T) {
+				description, err := Describe(doc, lang)
+				assert.NoError(t, err)
+				assert.Equal(t, want, description)
+			})
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package reactor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Rules of the lint.
+const (
+	MissingLabel        = "missing-label"
+	CapacityWithoutWhen = "capacity-without-when"
+	EmptySequence       = "empty-sequence"
+	UnreachableSelector = "unreachable-selector"
+	ExclusionClash      = "exclusion-clash"
+)
+
+// Finding is an authoring mistake found by Lint at a path of the reactor.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s [%s]", f.Path, f.Message, f.Rule)
+}
+
+// Lint looks through a decoded reactor, and the reactors its buffs carry,
+// for mistakes which are valid but make skills misbehave or confuse the
+// battle logs. Findings are listed in path order.
+func Lint(doc any) []Finding {
+	var findings []Finding
+	if m, ok := doc.(map[string]any); ok {
+		lintReactor("$", m, &findings)
+	}
+
+	Walk(doc, func(path string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+
+		if _, ok := m["_verb"]; ok {
+			if r, ok := m["reactor"].(map[string]any); ok {
+				lintReactor(member(path, "reactor"), r, &findings)
+			}
+		}
+
+		switch m["_kind"] {
+		case "sequence":
+			if do, _ := m["do"].([]any); len(do) == 0 {
+				findings = append(findings, Finding{EmptySequence, path, "sequence does nothing"})
+			}
+		case "pipeline":
+			lintPipeline(member(path, "selectors"), m["selectors"], &findings)
+		}
+	})
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+
+	return findings
+}
+
+// lintReactor checks the members of a reactor: its tags and capacity.
+func lintReactor(path string, m map[string]any, findings *[]Finding) {
+	tags, _ := m["tags"].([]any)
+
+	var labeled bool
+	var group any
+	var groupPath string
+	for i, tag := range tags {
+		tag, ok := tag.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		p := fmt.Sprintf("%s[%d]", member(path, "tags"), i)
+		switch tag["_kind"] {
+		case "label":
+			labeled = true
+		case "exclusion_group":
+			if groupPath == "" {
+				group, groupPath = tag["index"], p
+				continue
+			}
+			*findings = append(*findings, Finding{
+				ExclusionClash,
+				p,
+				fmt.Sprintf("exclusion group %s clashes with exclusion group %s at %s", quote(tag["index"]), quote(group), groupPath),
+			})
+		}
+	}
+	if !labeled {
+		*findings = append(*findings, Finding{MissingLabel, path, `no label tag, so battle logs show the reactor as "Unknown"`})
+	}
+
+	if capacity, ok := m["capacity"].(map[string]any); ok {
+		if when, _ := capacity["when"].([]any); len(when) == 0 {
+			*findings = append(*findings, Finding{CapacityWithoutWhen, member(path, "capacity"), "capacity with no when is never used up"})
+		}
+	}
+}
+
+// lintPipeline reports the first selector of a pipeline which leaves nobody
+// to select, a front of none or a side opposite to one selected before, and
+// the selectors after it.
+func lintPipeline(path string, v any, findings *[]Finding) {
+	selectors, _ := v.([]any)
+
+	var side any
+	var sidePath string
+	for i, selector := range selectors {
+		selector, ok := selector.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var empty string
+		switch selector["_kind"] {
+		case "front":
+			if n, ok := selector["count"].(json.Number); ok && n.String() == "0" {
+				empty = "selects nobody"
+			}
+		case "side":
+			if sidePath == "" {
+				side, sidePath = selector["side"], fmt.Sprintf("%s[%d]", path, i)
+			} else if selector["side"] != side {
+				empty = "contradicts the side selected at " + sidePath
+			}
+		}
+		if empty == "" {
+			continue
+		}
+
+		p := fmt.Sprintf("%s[%d]", path, i)
+		*findings = append(*findings, Finding{UnreachableSelector, p, empty + ", so the pipeline selects nobody"})
+		for j := i + 1; j < len(selectors); j++ {
+			*findings = append(*findings, Finding{
+				UnreachableSelector,
+				fmt.Sprintf("%s[%d]", path, j),
+				"unreachable after " + p,
+			})
+		}
+		return
+	}
+}
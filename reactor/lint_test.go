@@ -0,0 +1,60 @@
+package reactor_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		doc      string
+		findings []Finding
+	}{
+		{
+			"clean",
+			`{"tags":[{"_kind":"label","text":"Strike"}],"capacity":{"count":1,"when":[{"signal":"round_end"}]}}`,
+			nil,
+		},
+		{
+			"reactor",
+			`{
+				"tags":[{"_kind":"exclusion_group","index":0},{"_kind":"priority","index":10},{"_kind":"exclusion_group","index":1}],
+				"capacity":{"count":1},
+				"respond":{"when":{"signal":"launch"},"then":{"_kind":"sequence","do":[]}}
+			}`,
+			[]Finding{
+				{MissingLabel, "$", `no label tag, so battle logs show the reactor as "Unknown"`},
+				{CapacityWithoutWhen, "$.capacity", "capacity with no when is never used up"},
+				{EmptySequence, "$.respond.then", "sequence does nothing"},
+				{ExclusionClash, "$.tags[2]", "exclusion group 1 clashes with exclusion group 0 at $.tags[0]"},
+			},
+		},
+		{
+			"pipeline",
+			`{
+				"tags":[{"_kind":"label","text":"Strike"}],
+				"respond":{"then":{"_kind":"select","do":{"_kind":"verb","verb":{"_verb":"buff","reactor":{"capacity":{"count":2,"when":[]}}}},"selector":{"_kind":"pipeline","selectors":[
+					{"_kind":"side","side":false},
+					{"_kind":"front","count":1},
+					{"_kind":"side","side":true},
+					{"_kind":"shuffle"}
+				]}}}
+			}`,
+			[]Finding{
+				{MissingLabel, "$.respond.then.do.verb.reactor", `no label tag, so battle logs show the reactor as "Unknown"`},
+				{CapacityWithoutWhen, "$.respond.then.do.verb.reactor.capacity", "capacity with no when is never used up"},
+				{UnreachableSelector, "$.respond.then.selector.selectors[2]", "contradicts the side selected at $.respond.then.selector.selectors[0], so the pipeline selects nobody"},
+				{UnreachableSelector, "$.respond.then.selector.selectors[3]", "unreachable after $.respond.then.selector.selectors[2]"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Unmarshal([]byte(tt.doc))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.findings, Lint(doc))
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package reactor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The skill notation is a compact text form of JSON documents, written for
+// reactors:
+//
+//	# the normal attack
+//	tags: [exclusion_group(index: 0), label(text: NormalAttack)]
+//	respond: (
+//	  when: (signal: launch)
+//	  then: sequence(do: [select(...)])
+//	)
+//
+// A document is a list of members, key: value, separated by commas or line
+// breaks. (members) is an object and kind(members) is an object of that
+// _kind. [values] is an array. Words are strings unless they are true, false
+// or null; other strings are quoted as in JSON. Numbers are written as in
+// JSON. # starts a comment.
+
+// lineWidth is the width Format fits objects and arrays on one line within.
+const lineWidth = 80
+
+// SyntaxError is a mistake in the skill notation, at a line and a column
+// counted from 1.
+type SyntaxError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Parse reads a document in the skill notation into the values Unmarshal
+// decodes JSON into.
+func Parse(src []byte) (any, error) {
+	p := &parser{src: src, line: 1, column: 1}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	return p.members(tokenEOF)
+}
+
+// Format writes a decoded JSON document in the skill notation. An object is
+// written as a list of members, one per line.
+func Format(doc any) []byte {
+	var b bytes.Buffer
+	if m, ok := doc.(map[string]any); ok {
+		for _, k := range sortedKeys(m) {
+			head := formatKey(k) + ": "
+			b.WriteString(head + format(m[k], "", utf8.RuneCountInString(head)) + "\n")
+		}
+	} else {
+		b.WriteString(format(doc, "", 0) + "\n")
+	}
+
+	return b.Bytes()
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNewline
+	tokenComma
+	tokenColon
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenWord
+	tokenString
+	tokenNumber
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenEOF:      "end of input",
+	tokenNewline:  "line break",
+	tokenComma:    `","`,
+	tokenColon:    `":"`,
+	tokenLParen:   `"("`,
+	tokenRParen:   `")"`,
+	tokenLBracket: `"["`,
+	tokenRBracket: `"]"`,
+}
+
+type token struct {
+	kind         tokenKind
+	text         string
+	line, column int
+}
+
+func (t token) String() string {
+	if name, ok := tokenNames[t.kind]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	src          []byte
+	pos          int
+	line, column int
+	tok          token
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{t.line, t.column, fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected(expecting string) error {
+	return p.errorf(p.tok, "unexpected %s, expecting %s", p.tok, expecting)
+}
+
+// members reads key: value pairs up to the end token, which is consumed.
+func (p *parser) members(end tokenKind) (map[string]any, error) {
+	m := make(map[string]any)
+	for {
+		if err := p.skip(tokenNewline, tokenComma); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == end {
+			return m, p.next()
+		}
+
+		key := p.tok
+		if key.kind != tokenWord && key.kind != tokenString {
+			return nil, p.unexpected("a key")
+		}
+		k, err := p.text(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[k]; ok {
+			return nil, p.errorf(key, "duplicate key %q", k)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenColon {
+			return nil, p.unexpected(`":"`)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+
+		if m[k], err = p.value(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != end && p.tok.kind != tokenNewline && p.tok.kind != tokenComma {
+			return nil, p.unexpected(fmt.Sprintf(`"," or %s`, tokenNames[end]))
+		}
+	}
+}
+
+func (p *parser) values() ([]any, error) {
+	a := make([]any, 0)
+	for {
+		if err := p.skip(tokenNewline, tokenComma); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenRBracket {
+			return a, p.next()
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+		if p.tok.kind != tokenRBracket && p.tok.kind != tokenNewline && p.tok.kind != tokenComma {
+			return nil, p.unexpected(`"," or "]"`)
+		}
+	}
+}
+
+func (p *parser) value() (any, error) {
+	if err := p.skip(tokenNewline); err != nil {
+		return nil, err
+	}
+
+	t := p.tok
+	switch t.kind {
+	case tokenLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return p.members(tokenRParen)
+
+	case tokenLBracket:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return p.values()
+
+	case tokenNumber:
+		return json.Number(t.text), p.next()
+
+	case tokenString:
+		s, err := p.text(t)
+		if err != nil {
+			return nil, err
+		}
+		return s, p.next()
+
+	case tokenWord:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenLParen && p.tok.line == t.line && p.tok.column == t.column+utf8.RuneCountInString(t.text) {
+			open := p.tok
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			m, err := p.members(tokenRParen)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := m["_kind"]; ok {
+				return nil, p.errorf(open, "%s( has a _kind already", t.text)
+			}
+			m["_kind"] = t.text
+			return m, nil
+		}
+
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return t.text, nil
+		}
+	}
+
+	return nil, p.unexpected("a value")
+}
+
+// text is the string a word or a quoted string stands for.
+func (p *parser) text(t token) (string, error) {
+	if t.kind == tokenWord {
+		return t.text, nil
+	}
+
+	var s string
+	if err := json.Unmarshal([]byte(t.text), &s); err != nil {
+		return "", p.errorf(t, "invalid string %s", t.text)
+	}
+
+	return s, nil
+}
+
+func (p *parser) skip(kinds ...tokenKind) error {
+	for {
+		var skipped bool
+		for _, kind := range kinds {
+			if p.tok.kind == kind {
+				if err := p.next(); err != nil {
+					return err
+				}
+				skipped = true
+			}
+		}
+		if !skipped {
+			return nil
+		}
+	}
+}
+
+// next reads the next token, skipping blanks and comments.
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		r, _ := utf8.DecodeRune(p.src[p.pos:])
+		if r == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.advance()
+			}
+		} else if r != '\n' && unicode.IsSpace(r) {
+			p.advance()
+		} else {
+			break
+		}
+	}
+
+	t := token{line: p.line, column: p.column}
+	if p.pos == len(p.src) {
+		t.kind = tokenEOF
+		p.tok = t
+		return nil
+	}
+
+	start := p.pos
+	r, _ := utf8.DecodeRune(p.src[p.pos:])
+	switch {
+	case r == '\n':
+		t.kind = tokenNewline
+		p.advance()
+	case r == ',':
+		t.kind = tokenComma
+		p.advance()
+	case r == ':':
+		t.kind = tokenColon
+		p.advance()
+	case r == '(':
+		t.kind = tokenLParen
+		p.advance()
+	case r == ')':
+		t.kind = tokenRParen
+		p.advance()
+	case r == '[':
+		t.kind = tokenLBracket
+		p.advance()
+	case r == ']':
+		t.kind = tokenRBracket
+		p.advance()
+	case r == '"':
+		t.kind = tokenString
+		p.advance()
+		for {
+			if p.pos == len(p.src) || p.src[p.pos] == '\n' {
+				return p.errorf(t, "unterminated string")
+			}
+			c := p.src[p.pos]
+			p.advance()
+			if c == '\\' && p.pos < len(p.src) {
+				p.advance()
+			} else if c == '"' {
+				break
+			}
+		}
+	case r == '-' || r >= '0' && r <= '9':
+		t.kind = tokenNumber
+		for p.pos < len(p.src) && strings.ContainsRune("+-.eE0123456789", rune(p.src[p.pos])) {
+			p.advance()
+		}
+		if !json.Valid(p.src[start:p.pos]) {
+			return p.errorf(t, "invalid number %s", p.src[start:p.pos])
+		}
+	case isWordRune(r, true):
+		t.kind = tokenWord
+		for p.pos < len(p.src) {
+			r, _ := utf8.DecodeRune(p.src[p.pos:])
+			if !isWordRune(r, false) {
+				break
+			}
+			p.advance()
+		}
+	default:
+		return p.errorf(t, "unexpected character %q", r)
+	}
+
+	t.text = string(p.src[start:p.pos])
+	p.tok = t
+	return nil
+}
+
+func (p *parser) advance() {
+	r, n := utf8.DecodeRune(p.src[p.pos:])
+	p.pos += n
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+}
+
+func isWordRune(r rune, first bool) bool {
+	return r == '_' || unicode.IsLetter(r) || !first && (r == '-' || unicode.IsDigit(r))
+}
+
+// isWord tells whether a string may be written as a word.
+func isWord(s string) bool {
+	if s == "" || s == "true" || s == "false" || s == "null" {
+		return false
+	}
+	for i, r := range s {
+		if !isWordRune(r, i == 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// format writes a value on one line if it fits within lineWidth, starting
+// at column used, or else over several lines indented from indent.
+func format(v any, indent string, used int) string {
+	if s := formatInline(v); used+utf8.RuneCountInString(s) <= lineWidth {
+		return s
+	}
+
+	inner := indent + "  "
+	var b strings.Builder
+	switch v := v.(type) {
+	case map[string]any:
+		kind, _ := v["_kind"].(string)
+		if isWord(kind) {
+			b.WriteString(kind)
+		}
+		b.WriteString("(\n")
+		for _, k := range sortedKeys(v) {
+			if k == "_kind" && isWord(kind) {
+				continue
+			}
+			head := inner + formatKey(k) + ": "
+			b.WriteString(head + format(v[k], inner, utf8.RuneCountInString(head)) + "\n")
+		}
+		b.WriteString(indent + ")")
+
+	case []any:
+		b.WriteString("[\n")
+		for _, u := range v {
+			b.WriteString(inner + format(u, inner, utf8.RuneCountInString(inner)) + "\n")
+		}
+		b.WriteString(indent + "]")
+	}
+
+	return b.String()
+}
+
+func formatInline(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		kind, _ := v["_kind"].(string)
+		var members []string
+		for _, k := range sortedKeys(v) {
+			if k == "_kind" && isWord(kind) {
+				continue
+			}
+			members = append(members, formatKey(k)+": "+formatInline(v[k]))
+		}
+		s := "(" + strings.Join(members, ", ") + ")"
+		if isWord(kind) {
+			s = kind + s
+		}
+		return s
+
+	case []any:
+		values := make([]string, len(v))
+		for i, u := range v {
+			values[i] = formatInline(u)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+
+	case string:
+		if isWord(v) {
+			return v
+		}
+		return quoteString(v)
+
+	case nil:
+		return "null"
+
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatKey(k string) string {
+	if isWord(k) {
+		return k
+	}
+
+	return quoteString(k)
+}
+
+// quoteString quotes a string as JSON does, leaving <, > and & be.
+func quoteString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,130 @@
+package reactor_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+const normalAttack = `# the normal attack
+tags: [exclusion_group(index: 0), label(text: NormalAttack)]
+respond: (
+  when: (signal: launch)
+  then: sequence(do: [
+    select(
+      selector: pipeline(selectors: [
+        side(side: false)
+        water_level(comparator: ">", evaluator: axis(axis: health), value: 0)
+        shuffle(preference: label(text: Taunt))
+        front(count: 1)
+      ])
+      do: verb(verb: (_verb: attack), evaluator: axis(axis: damage))
+    )
+  ])
+)
+`
+
+func TestParse(t *testing.T) {
+	b, err := os.ReadFile("../storage/fixtures/skills.yml")
+	assert.NoError(t, err)
+
+	var skills []struct {
+		Name    string `yaml:"name"`
+		Reactor string `yaml:"reactor"`
+	}
+	assert.NoError(t, yaml.Unmarshal(b, &skills))
+
+	want, err := Unmarshal([]byte(skills[0].Reactor))
+	assert.NoError(t, err)
+
+	doc, err := Parse([]byte(normalAttack))
+	assert.NoError(t, err)
+	assert.Equal(t, want, doc)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		src, err string
+	}{
+		{"tags: [label(text: a)", `line 1, column 22: unexpected end of input, expecting "," or "]"`},
+		{"respond: (\n  when: (signal: launch))\n)", `line 3, column 1: unexpected ")", expecting a key`},
+		{"a: 1\na: 2", `line 2, column 1: duplicate key "a"`},
+		{"a: label(_kind: b)", "line 1, column 9: label( has a _kind already"},
+		{`a: "b`, "line 1, column 4: unterminated string"},
+		{"a: 1.2.3", "line 1, column 4: invalid number 1.2.3"},
+		{"a: b c", `line 1, column 6: unexpected "c", expecting "," or end of input`},
+		{"a: ?", `line 1, column 4: unexpected character '?'`},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse([]byte(tt.src))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	b, err := os.ReadFile("../storage/fixtures/skills.yml")
+	assert.NoError(t, err)
+
+	var skills []struct {
+		Name    string `yaml:"name"`
+		Reactor string `yaml:"reactor"`
+	}
+	assert.NoError(t, yaml.Unmarshal(b, &skills))
+
+	for _, skill := range skills {
+		t.Run(skill.Name, func(t *testing.T) {
+			doc, err := Unmarshal([]byte(skill.Reactor))
+			assert.NoError(t, err)
+
+			text := Format(doc)
+			parsed, err := Parse(text)
+			assert.NoError(t, err)
+			assert.Equal(t, doc, parsed)
+		})
+	}
+
+	doc, err := Parse([]byte(normalAttack))
+	assert.NoError(t, err)
+	assert.Equal(t, `respond: (
+  then: sequence(
+    do: [
+      select(
+        do: verb(evaluator: axis(axis: damage), verb: (_verb: attack))
+        selector: pipeline(
+          selectors: [
+            side(side: false)
+            water_level(
+              comparator: ">"
+              evaluator: axis(axis: health)
+              value: 0
+            )
+            shuffle(preference: label(text: Taunt))
+            front(count: 1)
+          ]
+        )
+      )
+    ]
+  )
+  when: (signal: launch)
+)
+tags: [exclusion_group(index: 0), label(text: NormalAttack)]
+`, string(Format(doc)))
+
+	doc, err = Unmarshal([]byte(`{"tags": [{"_kind": "ラベル", "text": "眠り"}]}`))
+	assert.NoError(t, err)
+	text := Format(doc)
+	assert.Equal(t, "tags: [ラベル(text: 眠り)]\n", string(text))
+	parsed, err := Parse(text)
+	assert.NoError(t, err)
+	assert.Equal(t, doc, parsed)
+
+	label := strings.Repeat("眠り", 15)
+	doc, err = Unmarshal([]byte(`{"tags": [{"_kind": "exclusion_group", "index": 0}, {"_kind": "label", "text": "` + label + `"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "tags: [exclusion_group(index: 0), label(text: "+label+")]\n", string(Format(doc)))
+}
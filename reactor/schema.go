@@ -0,0 +1,97 @@
+package reactor
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Schema is the JSON Schema of reactor documents.
+//
+//go:embed reactor.schema.json
+var Schema []byte
+
+const schemaURL = "https://github.com/farseeingnorthwest/battleground.go/reactor/reactor.schema.json"
+
+var schema = compileSchema()
+
+func compileSchema() *jsonschema.Schema {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(schemaURL, bytes.NewReader(Schema)); err != nil {
+		panic(err)
+	}
+
+	return c.MustCompile(schemaURL)
+}
+
+// Validate checks a decoded reactor against Schema, and reports where it
+// does not conform with the paths of the offending values.
+func Validate(doc any) []string {
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return []string{"$: " + err.Error()}
+	}
+
+	var problems []string
+	leaves(ve, func(e *jsonschema.ValidationError) {
+		problems = append(problems, fmt.Sprintf("%s: %s", pointerPath(e.InstanceLocation), e.Message))
+	})
+	sort.Strings(problems)
+
+	return dedupe(problems)
+}
+
+// leaves calls f with the errors which have no cause, the ones telling what
+// is actually wrong.
+func leaves(e *jsonschema.ValidationError, f func(*jsonschema.ValidationError)) {
+	if len(e.Causes) == 0 {
+		f(e)
+		return
+	}
+
+	for _, cause := range e.Causes {
+		leaves(cause, f)
+	}
+}
+
+// pointerPath turns a JSON pointer such as /tags/0 into a path such as
+// $.tags[0].
+func pointerPath(pointer string) string {
+	path := "$"
+	if pointer == "" {
+		return path
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if _, err := strconv.Atoi(token); err == nil {
+			path = fmt.Sprintf("%s[%s]", path, token)
+		} else {
+			path = member(path, token)
+		}
+	}
+
+	return path
+}
+
+func dedupe(sorted []string) []string {
+	out := sorted[:0]
+	for _, s := range sorted {
+		if len(out) == 0 || s != out[len(out)-1] {
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,50 @@
+package reactor_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidate_Fixtures(t *testing.T) {
+	b, err := os.ReadFile("../storage/fixtures/skills.yml")
+	assert.NoError(t, err)
+
+	var skills []struct {
+		Name    string `yaml:"name"`
+		Reactor string `yaml:"reactor"`
+	}
+	assert.NoError(t, yaml.Unmarshal(b, &skills))
+	assert.NotEmpty(t, skills)
+
+	for _, skill := range skills {
+		t.Run(skill.Name, func(t *testing.T) {
+			doc, err := Unmarshal([]byte(skill.Reactor))
+			assert.NoError(t, err)
+			assert.Empty(t, Validate(doc))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc, err := Unmarshal([]byte(`{
+		"tags": [{"_kind": "label"}, {"_kind": "faction", "value": "north"}],
+		"capacity": {"count": 1, "when": [{"signal": "turn_end"}]},
+		"respond": {
+			"when": {"signal": "launch"},
+			"then": {"_kind": "verb", "verb": {"_verb": "attack"}, "evaluator": {"_kind": "axis", "axis": "hp"}}
+		},
+		"cost": 3
+	}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`$.capacity.when[0].signal: value must be one of "battle_start", "launch", "lifecycle", "post_action", "pre_action", "round_end", "round_start"`,
+		`$.respond.then.evaluator.axis: value must be one of "critical_loss", "critical_odds", "damage", "defense", "health", "speed"`,
+		`$.tags[0]: missing properties: 'text'`,
+		`$: additionalProperties 'cost' not allowed`,
+	}, Validate(doc))
+}
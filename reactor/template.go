@@ -0,0 +1,175 @@
+package reactor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Placeholder is the member of the objects which stand for a parameter in a
+// template, as in {"_param": "multiplier"}.
+const Placeholder = "_param"
+
+// ParamTypes are the types a template parameter may take.
+var ParamTypes = []string{"boolean", "integer", "number", "string"}
+
+// Params declares the parameters of a template by name.
+type Params map[string]Param
+
+// Param is a typed parameter of a template, which may be left out by the
+// skills derived from the template if it has a default.
+type Param struct {
+	Type    string `json:"type"`
+	Default any    `json:"default,omitempty"`
+}
+
+// CheckTemplate looks through a decoded template for placeholders of
+// parameters which are not declared, and through the declarations for
+// unknown types and defaults of the wrong type, and reports them with their
+// paths.
+func CheckTemplate(doc any, params Params) []string {
+	var problems []string
+	for _, name := range sortedKeys(params) {
+		param := params[name]
+		path := member("params", name)
+		if !slices.Contains(ParamTypes, param.Type) {
+			problems = append(problems, fmt.Sprintf("%s: unknown type %s", path, quote(param.Type)))
+		} else if param.Default != nil {
+			if _, err := param.value(param.Default); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: default %s", path, err))
+			}
+		}
+	}
+
+	Walk(doc, func(path string, v any) {
+		if name, ok := placeholder(v); ok {
+			if _, ok := params[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown parameter %s", path, quote(name)))
+			}
+		}
+	})
+
+	return problems
+}
+
+// Render replaces the placeholders of a decoded template with the values
+// given to its parameters, or else their defaults. Values of unknown
+// parameters or of the wrong type, and parameters with neither, are
+// reported as problems.
+func Render(doc any, params Params, values map[string]any) (any, []string) {
+	var problems []string
+	for _, name := range sortedKeys(values) {
+		if _, ok := params[name]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown parameter %s", quote(name)))
+		}
+	}
+
+	resolved := make(map[string]any, len(params))
+	for _, name := range sortedKeys(params) {
+		param := params[name]
+		v, ok := values[name]
+		if !ok || v == nil {
+			v = param.Default
+		}
+		if v == nil {
+			problems = append(problems, fmt.Sprintf("parameter %s is missing", quote(name)))
+			continue
+		}
+
+		var err error
+		if resolved[name], err = param.value(v); err != nil {
+			problems = append(problems, fmt.Sprintf("parameter %s %s", quote(name), err))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return render(doc, resolved), nil
+}
+
+func render(v any, values map[string]any) any {
+	if name, ok := placeholder(v); ok {
+		return values[name]
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, u := range v {
+			m[k] = render(u, values)
+		}
+		return m
+
+	case []any:
+		a := make([]any, len(v))
+		for i, u := range v {
+			a[i] = render(u, values)
+		}
+		return a
+
+	default:
+		return v
+	}
+}
+
+// placeholder tells the parameter an object stands for, if it is a
+// placeholder.
+func placeholder(v any) (string, bool) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+
+	name, ok := m[Placeholder].(string)
+	return name, ok
+}
+
+// value checks that v is of the type of the parameter, and turns numbers
+// into the json.Numbers Unmarshal decodes them into.
+func (p Param) value(v any) (any, error) {
+	switch p.Type {
+	case "boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case "string":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "integer", "number":
+		var f float64
+		switch v := v.(type) {
+		case json.Number:
+			var err error
+			if f, err = v.Float64(); err != nil {
+				return nil, p.mismatch()
+			}
+		case float64:
+			f = v
+		case int:
+			f = float64(v)
+		default:
+			return nil, p.mismatch()
+		}
+		if p.Type == "number" {
+			return json.Number(strconv.FormatFloat(f, 'f', -1, 64)), nil
+		}
+		if f == math.Trunc(f) {
+			return json.Number(strconv.FormatInt(int64(f), 10)), nil
+		}
+		return nil, p.mismatch()
+	}
+
+	return nil, p.mismatch()
+}
+
+func (p Param) mismatch() error {
+	if p.Type == "integer" {
+		return fmt.Errorf("must be an integer")
+	}
+
+	return fmt.Errorf("must be a %s", p.Type)
+}
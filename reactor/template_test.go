@@ -0,0 +1,64 @@
+package reactor_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+)
+
+const strikeTemplate = `{
+  "tags": [{"_kind": "label", "text": "Strike"}],
+  "respond": {
+    "when": {"signal": "launch"},
+    "then": {
+      "_kind": "select",
+      "selector": {"_kind": "front", "count": {"_param": "targets"}},
+      "do": {
+        "_kind": "verb",
+        "verb": {"_verb": "attack"},
+        "evaluator": {"_kind": "multiplier", "multiplier": {"_param": "multiplier"}, "evaluator": {"_kind": "axis", "axis": "damage"}}
+      }
+    }
+  }
+}`
+
+var strikeParams = Params{
+	"targets":    {Type: "integer", Default: json.Number("1")},
+	"multiplier": {Type: "integer"},
+}
+
+func TestCheckTemplate(t *testing.T) {
+	doc, err := Unmarshal([]byte(strikeTemplate))
+	assert.NoError(t, err)
+
+	assert.Empty(t, CheckTemplate(doc, strikeParams))
+	assert.Equal(t, []string{
+		`params.ratio: unknown type "float"`,
+		`params.targets: default must be an integer`,
+		`$.respond.then.do.evaluator.multiplier: unknown parameter "multiplier"`,
+	}, CheckTemplate(doc, Params{
+		"ratio":   {Type: "float"},
+		"targets": {Type: "integer", Default: "one"},
+	}))
+}
+
+func TestRender(t *testing.T) {
+	doc, err := Unmarshal([]byte(strikeTemplate))
+	assert.NoError(t, err)
+
+	rendered, problems := Render(doc, strikeParams, map[string]any{"multiplier": float64(150)})
+	assert.Empty(t, problems)
+
+	m := rendered.(map[string]any)["respond"].(map[string]any)["then"].(map[string]any)
+	assert.Equal(t, json.Number("1"), m["selector"].(map[string]any)["count"])
+	assert.Equal(t, json.Number("150"), m["do"].(map[string]any)["evaluator"].(map[string]any)["multiplier"])
+
+	_, problems = Render(doc, strikeParams, map[string]any{"targets": 1.5, "power": true})
+	assert.Equal(t, []string{
+		`unknown parameter "power"`,
+		`parameter "multiplier" is missing`,
+		`parameter "targets" must be an integer`,
+	}, problems)
+}
@@ -0,0 +1,136 @@
+package reactor
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Kinds are the _kind values of the battlefield reactor file format, save
+// for those of tag types, which are registered at run time.
+var Kinds = []string{
+	// tags
+	"exclusion_group",
+	"label",
+	"priority",
+
+	// actions
+	"probability",
+	"repeat",
+	"select",
+	"sequence",
+	"verb",
+
+	// evaluators
+	"adder",
+	"axis",
+	"buff_count",
+	"constant",
+	"custom",
+	"multiplier",
+
+	// selectors
+	"absolute_side",
+	"current",
+	"front",
+	"healthiest",
+	"pipeline",
+	"shuffle",
+	"side",
+	"sort",
+	"source",
+	"water_level",
+
+	// conditions
+	"and",
+	"critical_strike",
+	"current_is_source",
+	"current_is_target",
+	"false",
+	"not",
+	"or",
+	"signal",
+	"tag",
+	"true",
+}
+
+// Signals are the signals a reactor may respond to or count.
+var Signals = []string{
+	"battle_start",
+	"launch",
+	"lifecycle",
+	"post_action",
+	"pre_action",
+	"round_end",
+	"round_start",
+}
+
+// Verbs are the _verb values of the verbs actions take.
+var Verbs = []string{
+	"attack",
+	"buff",
+	"heal",
+	"purge",
+}
+
+// Axes are the warrior stats an axis evaluator may read.
+var Axes = []string{
+	"critical_loss",
+	"critical_odds",
+	"damage",
+	"defense",
+	"health",
+	"speed",
+}
+
+// Check looks through a decoded reactor for _kinds, signals and evaluator
+// axes the battlefield does not know, and reports them with their paths.
+// Tags are also known by the names of the tag types given.
+func Check(doc any, tagTypes ...string) []string {
+	kinds := known(Kinds, tagTypes...)
+	signals := known(Signals)
+	axes := known(Axes)
+
+	var problems []string
+	Walk(doc, func(path string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+
+		if kind, ok := m["_kind"]; ok {
+			if s, ok := kind.(string); !ok || !kinds[s] {
+				problems = append(problems, fmt.Sprintf("%s: unknown _kind %s", path, quote(kind)))
+			} else if s == "axis" {
+				if axis, ok := m["axis"].(string); !ok || !axes[axis] {
+					problems = append(problems, fmt.Sprintf("%s: unknown axis %s", member(path, "axis"), quote(m["axis"])))
+				}
+			}
+		}
+		if signal, ok := m["signal"]; ok {
+			if s, ok := signal.(string); !ok || !signals[s] {
+				problems = append(problems, fmt.Sprintf("%s: unknown signal %s", member(path, "signal"), quote(signal)))
+			}
+		}
+	})
+
+	return problems
+}
+
+func known(names []string, more ...string) map[string]bool {
+	m := make(map[string]bool, len(names)+len(more))
+	for _, name := range append(append([]string(nil), names...), more...) {
+		m[name] = true
+	}
+
+	return m
+}
+
+// quote prints a value the way it is written in JSON.
+func quote(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
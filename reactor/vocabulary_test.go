@@ -0,0 +1,32 @@
+package reactor_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	doc, err := Unmarshal([]byte(`{
+		"tags": [{"_kind": "label", "text": "Sleep"}, {"_kind": "faction", "value": "north"}, {"_kind": "lable"}],
+		"capacity": {"count": 1, "when": [{"signal": "round_end"}, {"signal": "turn_end"}]},
+		"respond": {
+			"when": {"signal": "launch"},
+			"then": {"_kind": "verb", "verb": {"_verb": "attack"}, "evaluator": {"_kind": "axis", "axis": "hp"}}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`$.capacity.when[1].signal: unknown signal "turn_end"`,
+		`$.respond.then.evaluator.axis: unknown axis "hp"`,
+		`$.tags[2]: unknown _kind "lable"`,
+	}, Check(doc, "faction"))
+	assert.Equal(t, []string{
+		`$.capacity.when[1].signal: unknown signal "turn_end"`,
+		`$.respond.then.evaluator.axis: unknown axis "hp"`,
+		`$.tags[1]: unknown _kind "faction"`,
+		`$.tags[2]: unknown _kind "lable"`,
+	}, Check(doc))
+}
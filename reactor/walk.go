@@ -0,0 +1,34 @@
+package reactor
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Walk calls f with every value of a decoded JSON document and its path,
+// parents before their members and elements, members in key order.
+func Walk(v any, f func(path string, v any)) {
+	walk("$", v, f)
+}
+
+func walk(path string, v any, f func(string, any)) {
+	f(path, v)
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			walk(member(path, k), v[k], f)
+		}
+
+	case []any:
+		for i, u := range v {
+			walk(fmt.Sprintf("%s[%d]", path, i), u, f)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+)
+
+type RehashCmd struct{}
+
+// Run hashes the reactors of the skills stored before reactors were hashed,
+// then reports the skills which are identical but for their names.
+func (cmd RehashCmd) Run(globals *Globals) error {
+	db, err := globals.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tagTypes, err := tagtype.Open(globals.TagTypes, storage.NewTagTypeRepository(db))
+	if err != nil {
+		return err
+	}
+	tagTypes.Register()
+
+	repo := storage.NewSkillRepository(db)
+	n, err := repo.Rehash()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d skill(s) rehashed\n", n)
+
+	skills, err := repo.FindEx()
+	if err != nil {
+		return err
+	}
+
+	identical := make(map[string][]string)
+	for _, skill := range skills {
+		identical[skill.ReactorHash] = append(identical[skill.ReactorHash], fmt.Sprintf("skills/%d (%s)", skill.ID, skill.Name))
+	}
+	var groups []string
+	for _, names := range identical {
+		if len(names) > 1 {
+			groups = append(groups, strings.Join(names, ", "))
+		}
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		fmt.Printf("identical: %s\n", group)
+	}
+
+	return nil
+}
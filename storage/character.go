@@ -1,6 +1,17 @@
 package storage
 
-import "github.com/jmoiron/sqlx"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
 
 type Character struct {
 	ID           int
@@ -11,7 +22,114 @@ type Character struct {
 	CriticalLoss int `db:"critical_loss"`
 	Health       int
 	Speed        int
-	Skills       map[int]SkillMeta
+	Layout       SlotLayout
+	Tags         Tags
+	Growth       Growth
+	Revision     int
+	DeletedAt    *time.Time `db:"deleted_at"`
+	// ParentID is the character this one inherits the fields it does not
+	// set from.
+	ParentID *int `db:"parent_id"`
+	// Inherited names the fields taken from the ancestors of a child: stats
+	// and the layout by column name, growth when it has none of its own, and
+	// skills when it has no slots of its own.
+	Inherited pq.StringArray
+	Skills    map[int]SkillMeta
+}
+
+// Inherits tells if the character takes the field from its ancestors.
+func (c Character) Inherits(field string) bool {
+	for _, f := range c.Inherited {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// own is the value of a stat column as stored: NULL when inherited.
+func (c Character) own(stat string, value int) any {
+	if c.Inherits(stat) {
+		return nil
+	}
+
+	return value
+}
+
+// ownGrowth is the growth as stored: empty when inherited.
+func (c Character) ownGrowth() Growth {
+	if c.Inherits("growth") {
+		return nil
+	}
+
+	return c.Growth
+}
+
+// ownLayout is the layout as stored: nil when inherited.
+func (c Character) ownLayout() SlotLayout {
+	if c.Inherits("layout") {
+		return nil
+	}
+
+	return c.Layout
+}
+
+// inherit fills the fields the character inherits from its resolved parent.
+func (c Character) inherit(parent Character) Character {
+	for _, f := range []struct {
+		name          string
+		child, parent *int
+	}{
+		{"damage", &c.Damage, &parent.Damage},
+		{"defense", &c.Defense, &parent.Defense},
+		{"critical_odds", &c.CriticalOdds, &parent.CriticalOdds},
+		{"critical_loss", &c.CriticalLoss, &parent.CriticalLoss},
+		{"health", &c.Health, &parent.Health},
+		{"speed", &c.Speed, &parent.Speed},
+	} {
+		if c.Inherits(f.name) {
+			*f.child = *f.parent
+		}
+	}
+	if c.Inherits("layout") {
+		c.Layout = parent.Layout
+	}
+	if c.Inherits("growth") {
+		c.Growth = parent.Growth
+	}
+	if c.Inherits("skills") {
+		c.Skills = parent.Skills
+	}
+
+	return c
+}
+
+// Tags are the tags of a character, such as its element, in the JSON form of
+// reactor tags: [{"_kind": "element", ...}].
+type Tags []map[string]any
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal([]map[string]any(t))
+}
+
+func (t *Tags) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid argument")
+	}
+	if err := json.Unmarshal(b, (*[]map[string]any)(t)); err != nil {
+		return err
+	}
+	if len(*t) == 0 {
+		*t = nil
+	}
+
+	return nil
 }
 
 type CharacterSkill struct {
@@ -28,62 +146,145 @@ func NewCharacterRepository(db *sqlx.DB) *CharacterRepository {
 	return &CharacterRepository{db: db}
 }
 
+var characterColumns = map[string]string{
+	"id":            "id",
+	"name":          "name",
+	"damage":        "damage",
+	"defense":       "defense",
+	"critical_odds": "critical_odds",
+	"critical_loss": "critical_loss",
+	"health":        "health",
+	"speed":         "speed",
+}
+
+func characterValues(character Character) map[string]any {
+	return map[string]any{
+		"id":            character.ID,
+		"name":          character.Name,
+		"damage":        character.Damage,
+		"defense":       character.Defense,
+		"critical_odds": character.CriticalOdds,
+		"critical_loss": character.CriticalLoss,
+		"health":        character.Health,
+		"speed":         character.Speed,
+	}
+}
+
 func (r CharacterRepository) Find(ids ...int) ([]Character, error) {
+	return r.FindAsOf(time.Time{}, ids...)
+}
+
+// FindAsOf is Find against the characters as they were at the given time.
+func (r CharacterRepository) FindAsOf(asOf time.Time, ids ...int) ([]Character, error) {
+	characters, _, err := r.Query(CharacterQuery{Query: Query{IDs: ids, AsOf: asOf}})
+	return characters, err
+}
+
+// Query returns a page of characters along with the cursor of the next page,
+// which is empty on the last one.
+func (r CharacterRepository) Query(q CharacterQuery) ([]Character, string, error) {
+	clause, args, orders, err := q.compile(characterColumns)
+	if err != nil {
+		return nil, "", err
+	}
+
+	source, sourceArgs := characterSource(q.AsOf)
+	query, args, err := sqlx.In("SELECT * FROM "+source+clause, append(sourceArgs, args...)...)
+	if err != nil {
+		return nil, "", err
+	}
+
 	var characters []Character
-	if len(ids) == 0 {
-		if err := r.db.Select(&characters, "SELECT * FROM characters ORDER BY id"); err != nil {
-			return nil, err
-		}
-	} else {
-		query, args, err := sqlx.In("SELECT * FROM characters WHERE id IN (?) ORDER BY id", ids)
-		if err != nil {
-			return nil, err
-		}
+	if err := r.db.Select(&characters, r.db.Rebind(query), args...); err != nil {
+		return nil, "", err
+	}
 
-		if err := r.db.Select(&characters, r.db.Rebind(query), args...); err != nil {
-			return nil, err
-		}
+	characters, next, err := page(q.Query, characters, orders, characterValues)
+	if err != nil {
+		return nil, "", err
+	}
+
+	characters, err = r.getAllCharacterSkills(characters, q.AsOf)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return r.getAllCharacterSkills(characters)
+	return characters, next, nil
 }
 
 func (r CharacterRepository) Get(id int) (*Character, error) {
-	var character Character
-	if err := r.db.Get(&character, "SELECT * FROM characters WHERE id = $1", id); err != nil {
+	characters, err := r.Find(id)
+	if err != nil {
 		return nil, err
 	}
+	if len(characters) == 0 {
+		return nil, fmt.Errorf("characters/%d: %w", id, ErrNotFound)
+	}
 
-	return r.getCharacterSkills(&character)
+	character := characters[0]
+	if character.Skills == nil {
+		character.Skills = make(map[int]SkillMeta)
+	}
+
+	return &character, nil
 }
 
 func (r CharacterRepository) Create(character *Character) error {
-	if err := r.db.Get(
-		character, `
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if err := tx.Get(
+			character, `
 INSERT INTO
-    characters (name, damage, defense, critical_odds, critical_loss, health, speed)
+    characters (name, damage, defense, critical_odds, critical_loss, health, speed, layout, tags, growth, parent_id)
 VALUES
-    ($1, $2, $3, $4, $5, $6, $7)
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING
-    *
+    id, revision
 `,
-		character.Name,
-		character.Damage,
-		character.Defense,
-		character.CriticalOdds,
-		character.CriticalLoss,
-		character.Health,
-		character.Speed,
-	); err != nil {
-		return err
-	}
+			character.Name,
+			character.own("damage", character.Damage),
+			character.own("defense", character.Defense),
+			character.own("critical_odds", character.CriticalOdds),
+			character.own("critical_loss", character.CriticalLoss),
+			character.own("health", character.Health),
+			character.own("speed", character.Speed),
+			character.ownLayout(),
+			character.Tags,
+			character.ownGrowth(),
+			character.ParentID,
+		); err != nil {
+			return err
+		}
 
-	return r.saveCharacterSkills(character)
+		return saveCharacterSkills(tx, character)
+	})
 }
 
+// Update overwrites the character unless it has been changed since the
+// revision it carries, in which case ErrStale is returned. A zero revision
+// skips the check. ErrCycle is returned if the character would descend from
+// itself.
 func (r CharacterRepository) Update(character *Character) error {
-	if err := r.db.Get(
-		character, `
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if character.ParentID != nil {
+			var cycle bool
+			if err := tx.Get(&cycle, `
+WITH RECURSIVE ancestors AS (
+    SELECT id, parent_id FROM characters WHERE id = $1
+    UNION
+    SELECT c.id, c.parent_id FROM characters c JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)
+`,
+				*character.ParentID, character.ID); err != nil {
+				return err
+			}
+			if cycle {
+				return fmt.Errorf("characters/%d: %w", character.ID, ErrCycle)
+			}
+		}
+
+		if err := tx.Get(
+			character, `
 UPDATE
     characters
 SET
@@ -93,101 +294,217 @@ SET
     critical_odds = $4,
     critical_loss = $5,
     health = $6,
-    speed = $7
+    speed = $7,
+    layout = $8,
+    tags = $9,
+    growth = $10,
+    parent_id = $11,
+    revision = revision + 1
 WHERE
-    id = $8
-RETURNING *
+    id = $12 AND deleted_at IS NULL AND ($13 = 0 OR revision = $13)
+RETURNING
+    id, revision
 `,
-		character.Name,
-		character.Damage,
-		character.Defense,
-		character.CriticalOdds,
-		character.CriticalLoss,
-		character.Health,
-		character.Speed,
-		character.ID,
-	); err != nil {
-		return err
+			character.Name,
+			character.own("damage", character.Damage),
+			character.own("defense", character.Defense),
+			character.own("critical_odds", character.CriticalOdds),
+			character.own("critical_loss", character.CriticalLoss),
+			character.own("health", character.Health),
+			character.own("speed", character.Speed),
+			character.ownLayout(),
+			character.Tags,
+			character.ownGrowth(),
+			character.ParentID,
+			character.ID,
+			character.Revision,
+		); err != nil {
+			return checkRevision(tx, "characters", character.ID, err)
+		}
+
+		return saveCharacterSkills(tx, character)
+	})
+}
+
+// Delete moves the character to the trash, from where it can be restored
+// with its skill slots, unless forced to, which empties them.
+func (r CharacterRepository) Delete(id, revision int, force bool) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		var deleted int
+		err := tx.Get(&deleted, `
+UPDATE
+    characters
+SET
+    deleted_at = now()
+WHERE
+    id = $1 AND deleted_at IS NULL AND ($2 = 0 OR revision = $2)
+RETURNING id
+`,
+			id, revision)
+		if err != nil {
+			return checkRevision(tx, "characters", id, err)
+		}
+		if force {
+			return removeCharacterSkills(tx, id)
+		}
+
+		return nil
+	})
+}
+
+// Trash lists the deleted characters, the most recently deleted first.
+func (r CharacterRepository) Trash() ([]Character, error) {
+	resolved, args := resolvedCharacters(time.Time{})
+	var characters []Character
+	if err := r.db.Select(&characters, "SELECT * FROM ("+resolved+") AS resolved WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id", args...); err != nil {
+		return nil, err
 	}
 
-	return r.saveCharacterSkills(character)
+	return r.getAllCharacterSkills(characters, time.Time{})
 }
 
-func (r CharacterRepository) Delete(id int, force bool) error {
-	if force {
-		if err := r.removeCharacterSkills(id); err != nil {
-			return err
+// Restore takes the character out of the trash.
+func (r CharacterRepository) Restore(id int) (*Character, error) {
+	var restored int
+	if err := r.db.Get(&restored, "UPDATE characters SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("trash/characters/%d: %w", id, ErrNotFound)
 		}
+		return nil, err
 	}
-	if _, err := r.db.Exec("DELETE FROM characters WHERE id = $1", id); err != nil {
+
+	return r.Get(id)
+}
+
+// Purge deletes the character in the trash for good, unless other
+// characters inherit from it, in which case ErrInUse is returned.
+func (r CharacterRepository) Purge(id int) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		var parent bool
+		if err := tx.Get(&parent, "SELECT EXISTS (SELECT 1 FROM characters WHERE parent_id = $1)", id); err != nil {
+			return err
+		}
+		if parent {
+			return fmt.Errorf("trash/characters/%d: %w", id, ErrInUse)
+		}
+
+		if err := removeCharacterSkills(tx, id); err != nil {
+			return err
+		}
+
+		var purged int
+		err := tx.Get(&purged, "DELETE FROM characters WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("trash/characters/%d: %w", id, ErrNotFound)
+		}
+
 		return err
-	}
+	})
+}
 
-	return nil
+// characterSkillOwner is a slot of the nearest ancestor of a character,
+// itself included, which has slots.
+type characterSkillOwner struct {
+	CharacterSkill
+	OwnerID int `db:"owner_id"`
 }
 
-func (r CharacterRepository) getAllCharacterSkills(characters []Character) ([]Character, error) {
-	var skills []CharacterSkill
-	if err := r.db.Select(&skills, `
+func (r CharacterRepository) getAllCharacterSkills(characters []Character, asOf time.Time) ([]Character, error) {
+	if len(characters) == 0 {
+		return characters, nil
+	}
+
+	base, args := characterBase(asOf)
+	slots, slotArgs := characterSkillSource(asOf)
+	skills, skillArgs := skillSource(asOf)
+	args = append(args, functional.MapSlice(func(c Character) int { return c.ID }, characters))
+	query, args, err := sqlx.In(`
+WITH RECURSIVE base AS (`+base+`), chain AS (
+    SELECT id AS character_id, id AS owner_id, parent_id, 0 AS depth FROM base WHERE id IN (?)
+    UNION ALL
+    SELECT c.character_id, b.id, b.parent_id, c.depth + 1 FROM chain c JOIN base b ON b.id = c.parent_id WHERE c.depth < 64
+), slots AS (
+    SELECT
+        character_skills.character_id, character_skills.slot, skills.id, skills.name, skills.slot_type
+    FROM
+        `+slots+` JOIN
+            `+skills+` ON character_skills.skill_id = skills.id
+), owners AS (
+    SELECT DISTINCT ON (character_id)
+        character_id, owner_id
+    FROM
+        chain
+    WHERE
+        owner_id IN (SELECT character_id FROM slots)
+    ORDER BY
+        character_id, depth
+)
 SELECT
-    character_id, slot, id, name
+    o.character_id, o.owner_id, c.slot, c.id, c.name, c.slot_type
 FROM
-    character_skills c JOIN
-        skills s ON c.skill_id = s.id
+    owners o JOIN
+        slots c ON c.character_id = o.owner_id
 ORDER BY
-    character_id, slot
+    o.character_id, c.slot
 `,
-	); err != nil {
+		append(append(args, slotArgs...), skillArgs...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var metas []characterSkillOwner
+	if err := r.db.Select(&metas, r.db.Rebind(query), args...); err != nil {
 		return nil, err
 	}
 
 	var byCharacter = make(map[int]map[int]SkillMeta)
-	for _, skill := range skills {
+	owns := make(map[int]bool)
+	for _, skill := range metas {
 		if _, ok := byCharacter[skill.CharacterID]; !ok {
 			byCharacter[skill.CharacterID] = make(map[int]SkillMeta)
 		}
 		byCharacter[skill.CharacterID][skill.Slot] = skill.SkillMeta
+		owns[skill.CharacterID] = skill.OwnerID == skill.CharacterID
 	}
 	for i := range characters {
 		characters[i].Skills = byCharacter[characters[i].ID]
+		if characters[i].ParentID != nil && !owns[characters[i].ID] {
+			characters[i].Inherited = append(characters[i].Inherited, "skills")
+		}
 	}
 
 	return characters, nil
 }
 
-func (r CharacterRepository) getCharacterSkills(character *Character) (*Character, error) {
-	var skills []CharacterSkill
-	if err := r.db.Select(&skills, `
-SELECT
-    character_id, slot, id, name
-FROM
-    character_skills c JOIN
-        skills s ON c.skill_id = s.id
+// saveCharacterSkills replaces the slots of the character. Slots holding
+// skills in the trash are left for the skills to come back to, unless given
+// other skills.
+func saveCharacterSkills(tx *sqlx.Tx, character *Character) error {
+	if _, err := tx.Exec(`
+DELETE FROM
+    character_skills s
+USING
+    skills
 WHERE
-    character_id = $1
-ORDER BY
-    slot
+    s.skill_id = skills.id AND s.character_id = $1 AND skills.deleted_at IS NULL
 `,
 		character.ID); err != nil {
-		return nil, err
-	}
-
-	character.Skills = make(map[int]SkillMeta)
-	for _, skill := range skills {
-		character.Skills[skill.Slot] = skill.SkillMeta
-	}
-
-	return character, nil
-}
-
-func (r CharacterRepository) saveCharacterSkills(character *Character) error {
-	if err := r.removeCharacterSkills(character.ID); err != nil {
 		return err
 	}
+	if character.Inherits("skills") {
+		return nil
+	}
 
 	for slot, skill := range character.Skills {
-		if _, err := r.db.Exec(
-			"INSERT INTO character_skills (character_id, slot, skill_id) VALUES ($1, $2, $3)",
+		if _, err := tx.Exec(`
+INSERT INTO
+    character_skills (character_id, slot, skill_id)
+VALUES
+    ($1, $2, $3)
+ON CONFLICT (character_id, slot) DO UPDATE SET
+    skill_id = excluded.skill_id
+`,
 			character.ID, slot, skill.ID); err != nil {
 			return err
 		}
@@ -196,8 +513,8 @@ func (r CharacterRepository) saveCharacterSkills(character *Character) error {
 	return nil
 }
 
-func (r CharacterRepository) removeCharacterSkills(id int) error {
-	if _, err := r.db.Exec("DELETE FROM character_skills WHERE character_id = $1", id); err != nil {
+func removeCharacterSkills(tx *sqlx.Tx, id int) error {
+	if _, err := tx.Exec("DELETE FROM character_skills WHERE character_id = $1", id); err != nil {
 		return err
 	}
 
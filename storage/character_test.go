@@ -2,7 +2,9 @@ package storage_test
 
 import (
 	"testing"
+	"time"
 
+	"github.com/farseeingnorthwest/battleground.go/functional"
 	. "github.com/farseeingnorthwest/battleground.go/storage"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,8 +26,9 @@ func TestCharacterRepository_Find(t *testing.T) {
 					CriticalLoss: 200,
 					Health:       100,
 					Speed:        10,
+					Revision:     1,
 					Skills: map[int]SkillMeta{
-						1: {ID: 1, Name: "Normal Attack"},
+						0: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
 					},
 				},
 				{
@@ -37,6 +40,7 @@ func TestCharacterRepository_Find(t *testing.T) {
 					CriticalLoss: 200,
 					Health:       90,
 					Speed:        11,
+					Revision:     1,
 				},
 			},
 		},
@@ -52,8 +56,9 @@ func TestCharacterRepository_Find(t *testing.T) {
 					CriticalLoss: 200,
 					Health:       100,
 					Speed:        10,
+					Revision:     1,
 					Skills: map[int]SkillMeta{
-						1: {ID: 1, Name: "Normal Attack"},
+						0: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
 					},
 				},
 			},
@@ -71,6 +76,82 @@ func TestCharacterRepository_Find(t *testing.T) {
 	}
 }
 
+func TestCharacterRepository_Query(t *testing.T) {
+	for _, tt := range []struct {
+		query CharacterQuery
+		names []string
+		next  bool
+	}{
+		{CharacterQuery{}, []string{"Oda", "Ueno"}, false},
+		{CharacterQuery{Query: Query{Name: "UEN"}}, []string{"Ueno"}, false},
+		{CharacterQuery{Query: Query{Sort: []Order{{Key: "speed", Desc: true}}}}, []string{"Ueno", "Oda"}, false},
+		{CharacterQuery{Query: Query{Limit: 1}}, []string{"Oda"}, true},
+		{CharacterQuery{Stats: []StatRange{{"speed", ">=", 11}}}, []string{"Ueno"}, false},
+		{CharacterQuery{Stats: []StatRange{{"health", "<", 90}}}, nil, false},
+	} {
+		t.Run("", func(t *testing.T) {
+			loadFixtures(t)
+
+			r := NewCharacterRepository(db)
+			characters, next, err := r.Query(tt.query)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.names, functional.MapSlice(func(c Character) string { return c.Name }, characters))
+			assert.Equal(t, tt.next, next != "")
+		})
+	}
+}
+
+func TestCharacterRepository_Query_After(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewCharacterRepository(db)
+	q := CharacterQuery{Query: Query{Sort: []Order{{Key: "critical_odds", Desc: true}}, Limit: 1}}
+	first, next, err := r.Query(q)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ueno", first[0].Name)
+
+	q.After = next
+	second, next, err := r.Query(q)
+	assert.NoError(t, err)
+	assert.Equal(t, "Oda", second[0].Name)
+	assert.Empty(t, next)
+
+	q.Sort = []Order{{Key: "critical_odds"}}
+	_, _, err = r.Query(q)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	_, _, err = r.Query(CharacterQuery{Query: Query{After: "garbage"}})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
+
+func TestCharacterRepository_FindAsOf(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewCharacterRepository(db)
+	for _, tt := range []struct {
+		asOf   time.Time
+		count  int
+		damage int
+		slot   int
+		skill  string
+	}{
+		{time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), 0, 0, 0, ""},
+		{time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), 1, 8, 1, "Sleep"},
+		{time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC), 2, 10, 0, "Normal Attack"},
+	} {
+		t.Run(tt.asOf.Format(time.DateOnly), func(t *testing.T) {
+			characters, err := r.FindAsOf(tt.asOf)
+			assert.NoError(t, err)
+			if assert.Len(t, characters, tt.count) && tt.count > 0 {
+				assert.Equal(t, "Oda", characters[0].Name)
+				assert.Equal(t, tt.damage, characters[0].Damage)
+				assert.Equal(t, tt.skill, characters[0].Skills[tt.slot].Name)
+			}
+		})
+	}
+}
+
 func TestCharacterRepository_Get(t *testing.T) {
 	loadFixtures(t)
 
@@ -87,8 +168,9 @@ func TestCharacterRepository_Get(t *testing.T) {
 		CriticalLoss: 200,
 		Health:       100,
 		Speed:        10,
+		Revision:     1,
 		Skills: map[int]SkillMeta{
-			1: {ID: 1, Name: "Normal Attack"},
+			0: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
 		},
 	}, character)
 }
@@ -106,7 +188,7 @@ func TestCharacterRepository_Create(t *testing.T) {
 		Health:       80,
 		Speed:        9,
 		Skills: map[int]SkillMeta{
-			1: {ID: 1, Name: "Normal Attack"},
+			1: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
 		},
 	}
 	err := r.Create(&toy)
@@ -132,7 +214,7 @@ func TestCharacterRepository_Update(t *testing.T) {
 		Health:       80,
 		Speed:        9,
 		Skills: map[int]SkillMeta{
-			4: {ID: 2, Name: "Sleep"},
+			4: {ID: 2, Name: "Sleep", SlotType: SlotSpecial},
 		},
 	})
 	assert.NoError(t, err)
@@ -148,12 +230,32 @@ func TestCharacterRepository_Update(t *testing.T) {
 		CriticalLoss: 150,
 		Health:       80,
 		Speed:        9,
+		Revision:     2,
 		Skills: map[int]SkillMeta{
-			4: {ID: 2, Name: "Sleep"},
+			4: {ID: 2, Name: "Sleep", SlotType: SlotSpecial},
 		},
 	}, character)
 }
 
+func TestCharacterRepository_Update_Stale(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewCharacterRepository(db)
+	oda, err := r.Get(1)
+	assert.NoError(t, err)
+
+	oda.Speed = 12
+	assert.NoError(t, r.Update(oda))
+	assert.Equal(t, 2, oda.Revision)
+
+	oda.Revision = 1
+	oda.Speed = 13
+	assert.ErrorIs(t, r.Update(oda), ErrStale)
+
+	assert.ErrorIs(t, r.Delete(1, 1, true), ErrStale)
+	assert.ErrorIs(t, r.Update(&Character{ID: 3, Name: "Nobody"}), ErrNotFound)
+}
+
 func TestCharacterRepository_Delete(t *testing.T) {
 	for _, tt := range []struct {
 		id    int
@@ -161,24 +263,109 @@ func TestCharacterRepository_Delete(t *testing.T) {
 		ok    bool
 		count int
 	}{
-		{1, false, false, 2},
+		{1, false, true, 1},
 		{1, true, true, 1},
 		{2, false, true, 1},
+		{3, false, false, 2},
 	} {
 		t.Run("", func(t *testing.T) {
 			loadFixtures(t)
 
 			r := NewCharacterRepository(db)
-			err := r.Delete(tt.id, tt.force)
+			err := r.Delete(tt.id, 0, tt.force)
 			if tt.ok {
 				assert.NoError(t, err)
 			} else {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, ErrNotFound)
 			}
 
 			characters, err := r.Find()
 			assert.NoError(t, err)
 			assert.Len(t, characters, tt.count)
+
+			_, err = r.Get(tt.id)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCharacterRepository_Trash(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		t.Run("", func(t *testing.T) {
+			loadFixtures(t)
+
+			r := NewCharacterRepository(db)
+			assert.NoError(t, r.Delete(1, 0, force))
+
+			trash, err := r.Trash()
+			assert.NoError(t, err)
+			if assert.Len(t, trash, 1) {
+				assert.Equal(t, "Oda", trash[0].Name)
+				assert.NotNil(t, trash[0].DeletedAt)
+			}
+
+			oda, err := r.Restore(1)
+			assert.NoError(t, err)
+			assert.Nil(t, oda.DeletedAt)
+			assert.Len(t, oda.Skills, map[bool]int{false: 1, true: 0}[force])
+
+			_, err = r.Restore(1)
+			assert.ErrorIs(t, err, ErrNotFound)
 		})
 	}
 }
+
+func TestCharacterRepository_Purge(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewCharacterRepository(db)
+	assert.ErrorIs(t, r.Purge(1), ErrNotFound)
+	assert.NoError(t, r.Delete(1, 0, false))
+	assert.NoError(t, r.Purge(1))
+
+	trash, err := r.Trash()
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+}
+
+func TestCharacterRepository_Parent(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewCharacterRepository(db)
+	parentID := 1
+	child := &Character{
+		Name:      "Oda Jr.",
+		ParentID:  &parentID,
+		Damage:    12,
+		Inherited: []string{"defense", "critical_odds", "critical_loss", "health", "speed", "layout", "growth", "skills"},
+	}
+	assert.NoError(t, r.Create(child))
+
+	oda, err := r.Get(1)
+	assert.NoError(t, err)
+	oda.Health = 120
+	oda.Growth = Growth{"health": {PerLevel: 5}}
+	oda.Layout = SlotLayout{SlotNormal, SlotSpecial}
+	assert.NoError(t, r.Update(oda))
+
+	character, err := r.Get(child.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &parentID, character.ParentID)
+	assert.Equal(t, 12, character.Damage)
+	assert.Equal(t, 120, character.Health)
+	assert.True(t, character.Inherits("health"))
+	assert.Equal(t, oda.Growth, character.Growth)
+	assert.True(t, character.Inherits("growth"))
+	assert.Equal(t, oda.Layout, character.Layout)
+	assert.True(t, character.Inherits("layout"))
+	assert.False(t, character.Inherits("damage"))
+	assert.Equal(t, map[int]SkillMeta{
+		0: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
+	}, character.Skills)
+
+	oda.ParentID = &child.ID
+	assert.ErrorIs(t, r.Update(oda), ErrCycle)
+
+	assert.NoError(t, r.Delete(1, 0, true))
+	assert.ErrorIs(t, r.Purge(1), ErrInUse)
+}
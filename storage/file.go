@@ -0,0 +1,548 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Directory is a content tree holding one YAML document per record, laid out
+// as characters/<id>.yml and skills/<id>.yml in the format of the fixtures.
+type Directory struct {
+	root string
+}
+
+func NewDirectory(root string) *Directory {
+	return &Directory{root: root}
+}
+
+// characterDocument holds the fields a character sets itself: a child
+// leaves out the stats and skills it inherits.
+type characterDocument struct {
+	ID           int         `yaml:"id"`
+	Name         string      `yaml:"name"`
+	Parent       *int        `yaml:"parent,omitempty"`
+	Damage       *int        `yaml:"damage,omitempty"`
+	Defense      *int        `yaml:"defense,omitempty"`
+	CriticalOdds *int        `yaml:"critical_odds,omitempty"`
+	CriticalLoss *int        `yaml:"critical_loss,omitempty"`
+	Health       *int        `yaml:"health,omitempty"`
+	Speed        *int        `yaml:"speed,omitempty"`
+	Layout       SlotLayout  `yaml:"layout,omitempty"`
+	Tags         Tags        `yaml:"tags,omitempty"`
+	Growth       Growth      `yaml:"growth,omitempty"`
+	Skills       map[int]int `yaml:"skills,omitempty"`
+}
+
+func newCharacterDocument(character *Character) characterDocument {
+	own := func(stat string, value int) *int {
+		if character.Inherits(stat) {
+			return nil
+		}
+		return &value
+	}
+
+	doc := characterDocument{
+		ID:           character.ID,
+		Name:         character.Name,
+		Parent:       character.ParentID,
+		Damage:       own("damage", character.Damage),
+		Defense:      own("defense", character.Defense),
+		CriticalOdds: own("critical_odds", character.CriticalOdds),
+		CriticalLoss: own("critical_loss", character.CriticalLoss),
+		Health:       own("health", character.Health),
+		Speed:        own("speed", character.Speed),
+		Layout:       character.ownLayout(),
+		Tags:         character.Tags,
+		Growth:       character.ownGrowth(),
+	}
+	if len(character.Skills) > 0 && !character.Inherits("skills") {
+		doc.Skills = make(map[int]int, len(character.Skills))
+		for slot, skill := range character.Skills {
+			doc.Skills[slot] = skill.ID
+		}
+	}
+
+	return doc
+}
+
+// character is the character as the document stores it, with the fields it
+// inherits still to be filled from its parent.
+func (d characterDocument) character(skills map[int]SkillMeta) Character {
+	character := Character{
+		ID:       d.ID,
+		Name:     d.Name,
+		ParentID: d.Parent,
+		Layout:   d.Layout,
+		Tags:     d.Tags,
+		Growth:   d.Growth,
+	}
+	for _, f := range []struct {
+		name  string
+		stat  *int
+		value *int
+	}{
+		{"damage", &character.Damage, d.Damage},
+		{"defense", &character.Defense, d.Defense},
+		{"critical_odds", &character.CriticalOdds, d.CriticalOdds},
+		{"critical_loss", &character.CriticalLoss, d.CriticalLoss},
+		{"health", &character.Health, d.Health},
+		{"speed", &character.Speed, d.Speed},
+	} {
+		switch {
+		case f.value != nil:
+			*f.stat = *f.value
+		case d.Parent != nil:
+			character.Inherited = append(character.Inherited, f.name)
+		}
+	}
+	if d.Parent != nil && d.Layout == nil {
+		character.Inherited = append(character.Inherited, "layout")
+	}
+	if d.Parent != nil && len(d.Growth) == 0 {
+		character.Inherited = append(character.Inherited, "growth")
+	}
+	if d.Parent != nil && len(d.Skills) == 0 {
+		character.Inherited = append(character.Inherited, "skills")
+	}
+	if len(d.Skills) > 0 {
+		character.Skills = make(map[int]SkillMeta, len(d.Skills))
+		for slot, id := range d.Skills {
+			character.Skills[slot] = skills[id]
+		}
+	}
+
+	return character
+}
+
+type skillDocument struct {
+	ID       int      `yaml:"id"`
+	Name     string   `yaml:"name"`
+	SlotType SlotType `yaml:"slot_type,omitempty"`
+	Reactor  string   `yaml:"reactor"`
+}
+
+func newSkillDocument(skill *Skill) (skillDocument, error) {
+	v, err := skill.Reactor.Value()
+	if err != nil {
+		return skillDocument{}, err
+	}
+
+	var reactor bytes.Buffer
+	if err := json.Indent(&reactor, v.([]byte), "", "  "); err != nil {
+		return skillDocument{}, err
+	}
+	reactor.WriteByte('\n')
+
+	return skillDocument{
+		ID:       skill.ID,
+		Name:     skill.Name,
+		SlotType: skill.SlotType,
+		Reactor:  reactor.String(),
+	}, nil
+}
+
+func (d skillDocument) skill() (Skill, error) {
+	var reactor Reactor
+	if err := reactor.Scan([]byte(d.Reactor)); err != nil {
+		return Skill{}, fmt.Errorf("skill %d: %w", d.ID, err)
+	}
+
+	return Skill{
+		SkillMeta: d.meta(),
+		Reactor:   &reactor,
+	}, nil
+}
+
+// meta reads the skill's metadata. Documents without a slot type hold
+// special skills.
+func (d skillDocument) meta() SkillMeta {
+	slotType := d.SlotType
+	if slotType == "" {
+		slotType = SlotSpecial
+	}
+
+	return SkillMeta{
+		ID:       d.ID,
+		Name:     d.Name,
+		SlotType: slotType,
+	}
+}
+
+func (d *Directory) path(table string, id int) string {
+	return filepath.Join(d.root, table, strconv.Itoa(id)+".yml")
+}
+
+func (d *Directory) ids(table string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(d.root, table))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ids []int
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yml")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+func (d *Directory) read(table string, id int, v any) error {
+	b, err := os.ReadFile(d.path(table, id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s/%d: %w", table, id, ErrNotFound)
+		}
+		return err
+	}
+
+	return yaml.Unmarshal(b, v)
+}
+
+func (d *Directory) write(table string, id int, v any) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(d.root, table), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(d.path(table, id), b, 0o644)
+}
+
+func (d *Directory) remove(table string, id int) error {
+	if err := os.Remove(d.path(table, id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s/%d: %w", table, id, ErrNotFound)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (d *Directory) nextID(table string) (int, error) {
+	ids, err := d.ids(table)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 1, nil
+	}
+
+	return ids[len(ids)-1] + 1, nil
+}
+
+func (d *Directory) characterDocuments(ids ...int) ([]characterDocument, error) {
+	if len(ids) == 0 {
+		var err error
+		if ids, err = d.ids("characters"); err != nil {
+			return nil, err
+		}
+	} else {
+		ids = append([]int(nil), ids...)
+		sort.Ints(ids)
+	}
+
+	docs := make([]characterDocument, 0, len(ids))
+	for _, id := range ids {
+		var doc characterDocument
+		if err := d.read("characters", id, &doc); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func (d *Directory) skillDocuments(ids ...int) ([]skillDocument, error) {
+	if len(ids) == 0 {
+		var err error
+		if ids, err = d.ids("skills"); err != nil {
+			return nil, err
+		}
+	} else {
+		ids = append([]int(nil), ids...)
+		sort.Ints(ids)
+	}
+
+	docs := make([]skillDocument, 0, len(ids))
+	for _, id := range ids {
+		var doc skillDocument
+		if err := d.read("skills", id, &doc); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// FileCharacterRepository reads and writes the characters of a content
+// directory, for sync and lint. It is no backend of the API: files keep no
+// revisions, history or trash, so it has none of the methods built on them,
+// and content gets to the API by syncing the directory with the database.
+type FileCharacterRepository struct {
+	dir *Directory
+}
+
+func NewFileCharacterRepository(dir *Directory) *FileCharacterRepository {
+	return &FileCharacterRepository{dir: dir}
+}
+
+func (r FileCharacterRepository) Find(ids ...int) ([]Character, error) {
+	docs, err := r.dir.characterDocuments(ids...)
+	if err != nil {
+		return nil, err
+	}
+	skills, err := r.skillMetas()
+	if err != nil {
+		return nil, err
+	}
+
+	characters := make([]Character, len(docs))
+	for i, doc := range docs {
+		if characters[i], err = r.resolve(doc.character(skills), skills, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return characters, nil
+}
+
+func (r FileCharacterRepository) Get(id int) (*Character, error) {
+	var doc characterDocument
+	if err := r.dir.read("characters", id, &doc); err != nil {
+		return nil, err
+	}
+	skills, err := r.skillMetas()
+	if err != nil {
+		return nil, err
+	}
+
+	character, err := r.resolve(doc.character(skills), skills, nil)
+	if err != nil {
+		return nil, err
+	}
+	if character.Skills == nil {
+		character.Skills = make(map[int]SkillMeta)
+	}
+
+	return &character, nil
+}
+
+func (r FileCharacterRepository) Create(character *Character) error {
+	id, err := r.dir.nextID("characters")
+	if err != nil {
+		return err
+	}
+
+	character.ID = id
+	return r.dir.write("characters", id, newCharacterDocument(character))
+}
+
+func (r FileCharacterRepository) Update(character *Character) error {
+	if _, err := os.Stat(r.dir.path("characters", character.ID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("characters/%d: %w", character.ID, ErrNotFound)
+		}
+		return err
+	}
+
+	return r.dir.write("characters", character.ID, newCharacterDocument(character))
+}
+
+// Delete removes the file of the character. Files have no trash, so the
+// character is gone for good.
+func (r FileCharacterRepository) Delete(id int, force bool) error {
+	if !force {
+		var doc characterDocument
+		if err := r.dir.read("characters", id, &doc); err != nil {
+			return err
+		}
+		if len(doc.Skills) > 0 {
+			return fmt.Errorf("characters/%d: still has %d skill(s)", id, len(doc.Skills))
+		}
+	}
+
+	return r.dir.remove("characters", id)
+}
+
+// resolve fills the fields the character inherits from its ancestors.
+func (r FileCharacterRepository) resolve(character Character, skills map[int]SkillMeta, seen map[int]bool) (Character, error) {
+	if character.ParentID == nil {
+		return character, nil
+	}
+	if seen[character.ID] {
+		return character, fmt.Errorf("characters/%d: %w", character.ID, ErrCycle)
+	}
+	if seen == nil {
+		seen = make(map[int]bool)
+	}
+	seen[character.ID] = true
+
+	var doc characterDocument
+	if err := r.dir.read("characters", *character.ParentID, &doc); err != nil {
+		return character, err
+	}
+	parent, err := r.resolve(doc.character(skills), skills, seen)
+	if err != nil {
+		return character, err
+	}
+
+	return character.inherit(parent), nil
+}
+
+func (r FileCharacterRepository) skillMetas() (map[int]SkillMeta, error) {
+	docs, err := r.dir.skillDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	skills := make(map[int]SkillMeta, len(docs))
+	for _, doc := range docs {
+		skills[doc.ID] = doc.meta()
+	}
+
+	return skills, nil
+}
+
+// FileSkillRepository reads and writes the skills of a content directory,
+// for sync and lint. Like FileCharacterRepository, it is no backend of the
+// API.
+type FileSkillRepository struct {
+	dir *Directory
+}
+
+func NewFileSkillRepository(dir *Directory) *FileSkillRepository {
+	return &FileSkillRepository{dir: dir}
+}
+
+func (r FileSkillRepository) Find(ids ...int) ([]SkillMeta, error) {
+	docs, err := r.dir.skillDocuments(ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	skills := make([]SkillMeta, len(docs))
+	for i, doc := range docs {
+		skills[i] = doc.meta()
+	}
+
+	return skills, nil
+}
+
+func (r FileSkillRepository) FindEx(ids ...int) ([]Skill, error) {
+	docs, err := r.dir.skillDocuments(ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	skills := make([]Skill, len(docs))
+	for i, doc := range docs {
+		if skills[i], err = doc.skill(); err != nil {
+			return nil, err
+		}
+	}
+
+	return skills, nil
+}
+
+func (r FileSkillRepository) Get(id int) (*Skill, error) {
+	var doc skillDocument
+	if err := r.dir.read("skills", id, &doc); err != nil {
+		return nil, err
+	}
+
+	skill, err := doc.skill()
+	if err != nil {
+		return nil, err
+	}
+
+	return &skill, nil
+}
+
+func (r FileSkillRepository) Create(skill *Skill) error {
+	id, err := r.dir.nextID("skills")
+	if err != nil {
+		return err
+	}
+
+	skill.ID = id
+	return r.save(skill)
+}
+
+func (r FileSkillRepository) Update(skill *Skill) error {
+	if _, err := os.Stat(r.dir.path("skills", skill.ID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("skills/%d: %w", skill.ID, ErrNotFound)
+		}
+		return err
+	}
+
+	return r.save(skill)
+}
+
+// Delete removes the file of the skill for good, and with force, takes it
+// out of the slots of the characters holding it.
+func (r FileSkillRepository) Delete(id int, force bool) error {
+	characters, err := r.dir.characterDocuments()
+	if err != nil {
+		return err
+	}
+
+	for _, character := range characters {
+		for slot, skill := range character.Skills {
+			if skill != id {
+				continue
+			}
+			if !force {
+				return fmt.Errorf("skills/%d: used by characters/%d", id, character.ID)
+			}
+
+			delete(character.Skills, slot)
+			if err := r.dir.write("characters", character.ID, character); err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.dir.remove("skills", id)
+}
+
+func (r FileSkillRepository) save(skill *Skill) error {
+	doc, err := newSkillDocument(skill)
+	if err != nil {
+		return err
+	}
+
+	return r.dir.write("skills", skill.ID, doc)
+}
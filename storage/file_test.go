@@ -0,0 +1,203 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/storage"
+	b "github.com/farseeingnorthwest/playground/battlefield/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDirectory(t *testing.T) *Directory {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"skills/1.yml": `id: 1
+name: Normal Attack
+slot_type: normal
+reactor: |
+  {
+    "tags": [
+      {
+        "_kind": "label",
+        "text": "NormalAttack"
+      }
+    ]
+  }
+`,
+		"characters/1.yml": `id: 1
+name: Oda
+damage: 10
+defense: 5
+critical_odds: 10
+critical_loss: 200
+health: 100
+speed: 10
+skills:
+  1: 1
+`,
+		"characters/2.yml": `id: 2
+name: Ueno
+damage: 9
+defense: 4
+critical_odds: 20
+critical_loss: 200
+health: 90
+speed: 11
+`,
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewDirectory(root)
+}
+
+func TestFileCharacterRepository_Find(t *testing.T) {
+	r := NewFileCharacterRepository(newDirectory(t))
+	characters, err := r.Find()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Character{
+		{
+			ID:           1,
+			Name:         "Oda",
+			Damage:       10,
+			Defense:      5,
+			CriticalOdds: 10,
+			CriticalLoss: 200,
+			Health:       100,
+			Speed:        10,
+			Skills: map[int]SkillMeta{
+				1: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
+			},
+		},
+		{
+			ID:           2,
+			Name:         "Ueno",
+			Damage:       9,
+			Defense:      4,
+			CriticalOdds: 20,
+			CriticalLoss: 200,
+			Health:       90,
+			Speed:        11,
+		},
+	}, characters)
+}
+
+func TestFileCharacterRepository_Create(t *testing.T) {
+	r := NewFileCharacterRepository(newDirectory(t))
+	toy := Character{
+		Name:         "Toy",
+		Damage:       9,
+		Defense:      4,
+		CriticalOdds: 10,
+		CriticalLoss: 150,
+		Health:       80,
+		Speed:        9,
+		Skills: map[int]SkillMeta{
+			1: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
+		},
+	}
+	err := r.Create(&toy)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, toy.ID)
+
+	character, err := r.Get(toy.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &toy, character)
+}
+
+func TestFileCharacterRepository_Delete(t *testing.T) {
+	for _, tt := range []struct {
+		id    int
+		force bool
+		ok    bool
+		count int
+	}{
+		{1, false, false, 2},
+		{1, true, true, 1},
+		{2, false, true, 1},
+		{3, true, false, 2},
+	} {
+		t.Run("", func(t *testing.T) {
+			r := NewFileCharacterRepository(newDirectory(t))
+			err := r.Delete(tt.id, tt.force)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+
+			characters, err := r.Find()
+			assert.NoError(t, err)
+			assert.Len(t, characters, tt.count)
+		})
+	}
+}
+
+func TestFileSkillRepository_Get(t *testing.T) {
+	r := NewFileSkillRepository(newDirectory(t))
+	skill, err := r.Get(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Normal Attack", skill.Name)
+	assert.Contains(t, skill.Reactor.Tags(), b.Label("NormalAttack"))
+}
+
+func TestFileSkillRepository_Create(t *testing.T) {
+	r := NewFileSkillRepository(newDirectory(t))
+	taunt := Skill{
+		SkillMeta: SkillMeta{
+			Name: "Taunt",
+		},
+		Reactor: (*Reactor)(b.NewFatReactor(
+			b.FatTags(b.Label("Taunt")),
+			b.FatCapacity(b.NewSignalTrigger(&b.RoundEndSignal{}), 2),
+		)),
+	}
+	err := r.Create(&taunt)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, taunt.ID)
+
+	skill, err := r.Get(taunt.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Taunt", skill.Name)
+	assert.Contains(t, skill.Reactor.Tags(), b.Label("Taunt"))
+}
+
+func TestFileSkillRepository_Delete(t *testing.T) {
+	for _, tt := range []struct {
+		force bool
+		ok    bool
+		count int
+	}{
+		{false, false, 1},
+		{true, true, 0},
+	} {
+		t.Run("", func(t *testing.T) {
+			dir := newDirectory(t)
+			r := NewFileSkillRepository(dir)
+			err := r.Delete(1, tt.force)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+
+			skills, err := r.Find()
+			assert.NoError(t, err)
+			assert.Len(t, skills, tt.count)
+
+			oda, err := NewFileCharacterRepository(dir).Get(1)
+			assert.NoError(t, err)
+			assert.Len(t, oda.Skills, tt.count)
+		})
+	}
+}
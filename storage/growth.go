@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Growth is how the stats of a character grow with its level, by stat name.
+// The stats of the character itself are the ones at level 1, and stats
+// missing from the growth do not grow.
+type Growth map[string]StatGrowth
+
+// StatGrowth raises a stat by PerLevel every level past 1, unless a Table
+// lists the stat level by level from level 1, its last value holding for
+// the levels past its end.
+type StatGrowth struct {
+	PerLevel int   `json:"per_level,omitempty" yaml:"per_level,omitempty"`
+	Table    []int `json:"table,omitempty" yaml:"table,omitempty"`
+}
+
+func (g StatGrowth) stat(base, level int) int {
+	if len(g.Table) > 0 {
+		return g.Table[min(level, len(g.Table))-1]
+	}
+
+	return base + g.PerLevel*(level-1)
+}
+
+func (g Growth) Value() (driver.Value, error) {
+	if g == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(map[string]StatGrowth(g))
+}
+
+func (g *Growth) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid argument")
+	}
+	if err := json.Unmarshal(b, (*map[string]StatGrowth)(g)); err != nil {
+		return err
+	}
+	if len(*g) == 0 {
+		*g = nil
+	}
+
+	return nil
+}
+
+// AtLevel is the character with its stats grown to the level, which is at
+// least 1.
+func (c Character) AtLevel(level int) Character {
+	for name, stat := range map[string]*int{
+		"damage":        &c.Damage,
+		"defense":       &c.Defense,
+		"critical_odds": &c.CriticalOdds,
+		"critical_loss": &c.CriticalLoss,
+		"health":        &c.Health,
+		"speed":         &c.Speed,
+	} {
+		if g, ok := c.Growth[name]; ok {
+			*stat = g.stat(*stat, level)
+		}
+	}
+
+	return c
+}
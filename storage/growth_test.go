@@ -0,0 +1,37 @@
+package storage_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharacter_AtLevel(t *testing.T) {
+	c := Character{
+		Damage:  10,
+		Defense: 5,
+		Health:  100,
+		Growth: Growth{
+			"damage": {PerLevel: 2},
+			"health": {Table: []int{90, 120, 150}},
+		},
+	}
+
+	for _, tt := range []struct {
+		level                   int
+		damage, defense, health int
+	}{
+		{1, 10, 5, 90},
+		{2, 12, 5, 120},
+		{60, 128, 5, 150},
+	} {
+		t.Run("", func(t *testing.T) {
+			grown := c.AtLevel(tt.level)
+
+			assert.Equal(t, tt.damage, grown.Damage)
+			assert.Equal(t, tt.defense, grown.Defense)
+			assert.Equal(t, tt.health, grown.Health)
+		})
+	}
+}
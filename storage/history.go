@@ -0,0 +1,132 @@
+package storage
+
+import "time"
+
+// The sources below stand in for the characters, character_skills and skills
+// tables in queries. They leave out soft-deleted rows and, given a non-zero
+// time, read the tables as they were back then. Placeholders are in sqlx.In
+// style and their arguments go before the ones of the rest of the query.
+
+func characterSource(asOf time.Time) (string, []any) {
+	resolved, args := resolvedCharacters(asOf)
+	return "(SELECT * FROM (" + resolved + ") AS resolved WHERE deleted_at IS NULL) AS characters", args
+}
+
+// characterBase selects the rows of characters, deleted ones included, as
+// stored: children leave the stats they inherit NULL.
+func characterBase(asOf time.Time) (string, []any) {
+	if asOf.IsZero() {
+		return "SELECT * FROM characters", nil
+	}
+
+	return `
+SELECT
+    (jsonb_populate_record(NULL::characters, snapshot)).*
+FROM
+    character_history
+WHERE
+    valid_from <= ? AND ? < valid_to
+`, []any{asOf, asOf}
+}
+
+// resolvedCharacters selects the characters, deleted ones included, with
+// the stats, layout and growth children inherit taken from their nearest
+// ancestor setting them, and named in an inherited column. An empty growth
+// is inherited. Characters whose ancestry loops are left out.
+func resolvedCharacters(asOf time.Time) (string, []any) {
+	base, args := characterBase(asOf)
+	inherit := func(stat string) string {
+		return "COALESCE(c." + stat + ", p." + stat + ") AS " + stat
+	}
+	inherited := func(stat string) string {
+		return "CASE WHEN c." + stat + " IS NULL THEN '" + stat + "' END"
+	}
+
+	return `
+WITH RECURSIVE base AS (` + base + `), resolved AS (
+    SELECT
+        id,
+        name,
+        damage,
+        defense,
+        critical_odds,
+        critical_loss,
+        health,
+        speed,
+        revision,
+        deleted_at,
+        layout,
+        tags,
+        growth,
+        parent_id,
+        NULL::text[] AS inherited
+    FROM
+        base
+    WHERE
+        parent_id IS NULL
+    UNION ALL
+    SELECT
+        c.id,
+        c.name,
+        ` + inherit("damage") + `,
+        ` + inherit("defense") + `,
+        ` + inherit("critical_odds") + `,
+        ` + inherit("critical_loss") + `,
+        ` + inherit("health") + `,
+        ` + inherit("speed") + `,
+        c.revision,
+        c.deleted_at,
+        COALESCE(c.layout, p.layout) AS layout,
+        c.tags,
+        COALESCE(NULLIF(c.growth, '{}'), p.growth) AS growth,
+        c.parent_id,
+        NULLIF(array_remove(ARRAY[
+            ` + inherited("damage") + `,
+            ` + inherited("defense") + `,
+            ` + inherited("critical_odds") + `,
+            ` + inherited("critical_loss") + `,
+            ` + inherited("health") + `,
+            ` + inherited("speed") + `,
+            ` + inherited("layout") + `,
+            CASE WHEN c.growth = '{}' THEN 'growth' END
+        ], NULL), '{}') AS inherited
+    FROM
+        base c JOIN
+            resolved p ON c.parent_id = p.id
+)
+SELECT * FROM resolved`, args
+}
+
+func characterSkillSource(asOf time.Time) (string, []any) {
+	if asOf.IsZero() {
+		return "character_skills", nil
+	}
+
+	return `(
+SELECT
+    character_id, slot, skill_id
+FROM
+    character_skill_history
+WHERE
+    valid_from <= ? AND ? < valid_to
+) AS character_skills`, []any{asOf, asOf}
+}
+
+// skillSource rebuilds past skills from their revisions, so skills purged
+// since, whose revisions went with them, are missing.
+func skillSource(asOf time.Time) (string, []any) {
+	if asOf.IsZero() {
+		return "(SELECT * FROM skills WHERE deleted_at IS NULL) AS skills", nil
+	}
+
+	return `(
+SELECT DISTINCT ON (skill_id)
+    skill_id AS id, name, slot_type, reactor, revision
+FROM
+    skill_revisions
+WHERE
+    created_at <= ? AND skill_id NOT IN (SELECT id FROM skills WHERE deleted_at <= ?)
+ORDER BY
+    skill_id, revision DESC
+) AS skills`, []any{asOf, asOf}
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/jmoiron/sqlx"
+)
+
+// Item is a piece of equipment, which raises the stats of the character
+// wearing it and grants it the reactors of its skills.
+type Item struct {
+	ID       int
+	Name     string
+	Bonuses  Bonuses
+	Revision int
+	// Skills are the skills the item grants, those in the trash left out
+	// until they are restored.
+	Skills []SkillMeta `db:"-"`
+}
+
+// Bonuses are the stat bonuses of an item, by stat name.
+type Bonuses map[string]Bonus
+
+// Bonus adds Flat points to a stat, then raises it by Percent.
+type Bonus struct {
+	Flat    int `json:"flat,omitempty"`
+	Percent int `json:"percent,omitempty"`
+}
+
+func (b Bonuses) Value() (driver.Value, error) {
+	if b == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(map[string]Bonus(b))
+}
+
+func (b *Bonuses) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid argument")
+	}
+	if err := json.Unmarshal(j, (*map[string]Bonus)(b)); err != nil {
+		return err
+	}
+	if len(*b) == 0 {
+		*b = nil
+	}
+
+	return nil
+}
+
+// Equip is the character with the bonuses of the items added to its stats:
+// flat bonuses first, then percentages, both summed over the items.
+func (c Character) Equip(items ...Item) Character {
+	for name, stat := range map[string]*int{
+		"damage":        &c.Damage,
+		"defense":       &c.Defense,
+		"critical_odds": &c.CriticalOdds,
+		"critical_loss": &c.CriticalLoss,
+		"health":        &c.Health,
+		"speed":         &c.Speed,
+	} {
+		var flat, percent int
+		for _, item := range items {
+			flat += item.Bonuses[name].Flat
+			percent += item.Bonuses[name].Percent
+		}
+		*stat = (*stat + flat) * (100 + percent) / 100
+	}
+
+	return c
+}
+
+type itemSkill struct {
+	ItemID int `db:"item_id"`
+	SkillMeta
+}
+
+type characterItem struct {
+	CharacterID int `db:"character_id"`
+	ItemID      int `db:"item_id"`
+}
+
+type ItemRepository struct {
+	db *sqlx.DB
+}
+
+func NewItemRepository(db *sqlx.DB) *ItemRepository {
+	return &ItemRepository{db: db}
+}
+
+// Find returns the items by ID, or all of them if none is given.
+func (r ItemRepository) Find(ids ...int) ([]Item, error) {
+	var items []Item
+	if len(ids) == 0 {
+		if err := r.db.Select(&items, "SELECT * FROM items ORDER BY id"); err != nil {
+			return nil, err
+		}
+	} else {
+		query, args, err := sqlx.In("SELECT * FROM items WHERE id IN (?) ORDER BY id", ids)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.db.Select(&items, r.db.Rebind(query), args...); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.getAllItemSkills(items)
+}
+
+func (r ItemRepository) Get(id int) (*Item, error) {
+	var item Item
+	if err := r.db.Get(&item, "SELECT * FROM items WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+
+	items, err := r.getAllItemSkills([]Item{item})
+	if err != nil {
+		return nil, err
+	}
+
+	return &items[0], nil
+}
+
+func (r ItemRepository) Create(item *Item) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if err := tx.Get(item, "INSERT INTO items (name, bonuses) VALUES ($1, $2) RETURNING *", item.Name, item.Bonuses); err != nil {
+			return err
+		}
+
+		return saveItemSkills(tx, item)
+	})
+}
+
+// Update overwrites the item unless it has been changed since the revision
+// it carries, in which case ErrStale is returned. A zero revision skips the
+// check.
+func (r ItemRepository) Update(item *Item) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		err := tx.Get(item, `
+UPDATE
+    items
+SET
+    name = $1,
+    bonuses = $2,
+    revision = revision + 1
+WHERE
+    id = $3 AND ($4 = 0 OR revision = $4)
+RETURNING *
+`,
+			item.Name,
+			item.Bonuses,
+			item.ID,
+			item.Revision,
+		)
+		if err != nil {
+			return checkRowRevision(tx, "items", item.ID, err)
+		}
+
+		return saveItemSkills(tx, item)
+	})
+}
+
+// Delete removes the item. An item still equipped by a character is not
+// deleted and ErrInUse is returned, unless forced to, which takes it off
+// every character.
+func (r ItemRepository) Delete(id, revision int, force bool) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if !force {
+			var used bool
+			if err := tx.Get(&used, `
+SELECT EXISTS (
+    SELECT
+        1
+    FROM
+        character_items i JOIN
+            characters c ON i.character_id = c.id
+    WHERE
+        item_id = $1 AND c.deleted_at IS NULL
+)
+`,
+				id); err != nil {
+				return err
+			}
+			if used {
+				return fmt.Errorf("items/%d: %w", id, ErrInUse)
+			}
+		}
+
+		var deleted int
+		err := tx.Get(&deleted, "DELETE FROM items WHERE id = $1 AND ($2 = 0 OR revision = $2) RETURNING id", id, revision)
+		if err != nil {
+			return checkRowRevision(tx, "items", id, err)
+		}
+
+		return nil
+	})
+}
+
+// Equipment returns the items equipped by the characters, by character ID.
+func (r ItemRepository) Equipment(characterIDs ...int) (map[int][]int, error) {
+	if len(characterIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM character_items WHERE character_id IN (?) ORDER BY character_id, item_id", characterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []characterItem
+	if err := r.db.Select(&rows, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	equipment := make(map[int][]int)
+	for _, row := range rows {
+		equipment[row.CharacterID] = append(equipment[row.CharacterID], row.ItemID)
+	}
+
+	return equipment, nil
+}
+
+// Equip replaces the items equipped by the character.
+func (r ItemRepository) Equip(characterID int, itemIDs []int) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		var exists bool
+		if err := tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM characters WHERE id = $1 AND deleted_at IS NULL)", characterID); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("characters/%d: %w", characterID, ErrNotFound)
+		}
+
+		if _, err := tx.Exec("DELETE FROM character_items WHERE character_id = $1", characterID); err != nil {
+			return err
+		}
+		for _, id := range itemIDs {
+			if _, err := tx.Exec(
+				"INSERT INTO character_items (character_id, item_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+				characterID, id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r ItemRepository) getAllItemSkills(items []Item) ([]Item, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	source, args := skillSource(time.Time{})
+	query, args, err := sqlx.In(`
+SELECT
+    item_id, id, name, slot_type
+FROM
+    item_skills i JOIN
+        `+source+` s ON i.skill_id = s.id
+WHERE
+    item_id IN (?)
+ORDER BY
+    item_id, id
+`,
+		append(args, functional.MapSlice(func(item Item) int { return item.ID }, items))...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var skills []itemSkill
+	if err := r.db.Select(&skills, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	byItem := make(map[int][]SkillMeta)
+	for _, skill := range skills {
+		byItem[skill.ItemID] = append(byItem[skill.ItemID], skill.SkillMeta)
+	}
+	for i := range items {
+		items[i].Skills = byItem[items[i].ID]
+	}
+
+	return items, nil
+}
+
+func saveItemSkills(tx *sqlx.Tx, item *Item) error {
+	if _, err := tx.Exec("DELETE FROM item_skills WHERE item_id = $1", item.ID); err != nil {
+		return err
+	}
+
+	for _, skill := range item.Skills {
+		if _, err := tx.Exec(
+			"INSERT INTO item_skills (item_id, skill_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			item.ID, skill.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkRowRevision is checkRevision for the tables whose rows are deleted
+// for good.
+func checkRowRevision(tx *sqlx.Tx, table string, id int, err error) error {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var exists bool
+	if err := tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s/%d: %w", table, id, ErrStale)
+	}
+
+	return fmt.Errorf("%s/%d: %w", table, id, ErrNotFound)
+}
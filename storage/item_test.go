@@ -0,0 +1,63 @@
+package storage_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemRepository_Find(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewItemRepository(db)
+	items, err := r.Find()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Item{
+		{
+			ID:       1,
+			Name:     "Sword",
+			Bonuses:  Bonuses{"damage": {Flat: 5, Percent: 10}},
+			Revision: 1,
+			Skills:   []SkillMeta{{ID: 2, Name: "Sleep", SlotType: SlotSpecial}},
+		},
+	}, items)
+}
+
+func TestItemRepository_Delete(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewItemRepository(db)
+	assert.ErrorIs(t, r.Delete(1, 0, false), ErrInUse)
+	assert.ErrorIs(t, r.Delete(1, 2, true), ErrStale)
+	assert.NoError(t, r.Delete(1, 1, true))
+	assert.ErrorIs(t, r.Delete(1, 0, true), ErrNotFound)
+
+	equipment, err := r.Equipment(1)
+	assert.NoError(t, err)
+	assert.Empty(t, equipment)
+}
+
+func TestItemRepository_Equip(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewItemRepository(db)
+	assert.NoError(t, r.Equip(2, []int{1}))
+	assert.ErrorIs(t, r.Equip(3, []int{1}), ErrNotFound)
+
+	equipment, err := r.Equipment(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int][]int{1: {1}, 2: {1}}, equipment)
+}
+
+func TestCharacter_Equip(t *testing.T) {
+	c := Character{Damage: 10, Defense: 5, Health: 100}.Equip(
+		Item{Bonuses: Bonuses{"damage": {Flat: 5, Percent: 10}}},
+		Item{Bonuses: Bonuses{"damage": {Flat: 5}, "health": {Percent: -50}}},
+	)
+
+	assert.Equal(t, 22, c.Damage)
+	assert.Equal(t, 5, c.Defense)
+	assert.Equal(t, 50, c.Health)
+}
@@ -1,11 +1,63 @@
 package storage
 
-import "go.uber.org/fx"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+)
 
 var Module = fx.Module(
 	"storage",
 	fx.Provide(
 		NewCharacterRepository,
 		NewSkillRepository,
+		NewItemRepository,
+		NewTagTypeRepository,
+		NewSkillTemplateRepository,
 	),
 )
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrStale    = errors.New("stale revision")
+	ErrInUse    = errors.New("in use")
+	ErrCycle    = errors.New("inherits from itself")
+	ErrTemplate = errors.New("does not render")
+)
+
+// transact runs f in a transaction, which is committed unless f fails.
+func transact(db *sqlx.DB, f func(*sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkRevision tells apart why a conditional write to a row matched
+// nothing: either the row is gone or in the trash, or its revision has
+// moved on.
+func checkRevision(tx *sqlx.Tx, table string, id int, err error) error {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var exists bool
+	if err := tx.Get(&exists, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1 AND deleted_at IS NULL)", id); err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s/%d: %w", table, id, ErrStale)
+	}
+
+	return fmt.Errorf("%s/%d: %w", table, id, ErrNotFound)
+}
@@ -0,0 +1,4 @@
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ADD COLUMN "revision" integer NOT NULL DEFAULT 1;
+-- Modify "skills" table
+ALTER TABLE "public"."skills" ADD COLUMN "revision" integer NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Create "skill_revisions" table
+CREATE TABLE "public"."skill_revisions" ("skill_id" integer NOT NULL, "revision" integer NOT NULL, "name" character varying(255) NOT NULL, "reactor" jsonb NOT NULL, "author" character varying(255) NOT NULL, "created_at" timestamptz NOT NULL DEFAULT now(), PRIMARY KEY ("skill_id", "revision"), CONSTRAINT "skill_revisions_skill_id_fkey" FOREIGN KEY ("skill_id") REFERENCES "public"."skills" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Backfill the current revision of every skill
+INSERT INTO "public"."skill_revisions" ("skill_id", "revision", "name", "reactor", "author") SELECT "id", "revision", "name", "reactor", '' FROM "public"."skills";
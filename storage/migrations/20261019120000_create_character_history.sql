@@ -0,0 +1,37 @@
+-- Create "character_history" table
+CREATE TABLE "public"."character_history" ("id" integer NOT NULL, "snapshot" jsonb NOT NULL, "valid_from" timestamptz NOT NULL, "valid_to" timestamptz NOT NULL DEFAULT 'infinity');
+-- Create index "character_history_id_valid_from_idx" to table: "character_history"
+CREATE INDEX "character_history_id_valid_from_idx" ON "public"."character_history" ("id", "valid_from");
+-- Create "character_skill_history" table
+CREATE TABLE "public"."character_skill_history" ("character_id" integer NOT NULL, "slot" smallint NOT NULL, "skill_id" integer NOT NULL, "valid_from" timestamptz NOT NULL, "valid_to" timestamptz NOT NULL DEFAULT 'infinity');
+-- Create index "character_skill_history_character_id_valid_from_idx" to table: "character_skill_history"
+CREATE INDEX "character_skill_history_character_id_valid_from_idx" ON "public"."character_skill_history" ("character_id", "valid_from");
+-- Record every version of "characters"
+CREATE FUNCTION "public"."record_character_history"() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE "public"."character_history" SET "valid_to" = now() WHERE "id" = OLD."id" AND "valid_to" = 'infinity';
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    INSERT INTO "public"."character_history" ("id", "snapshot", "valid_from") VALUES (NEW."id", to_jsonb(NEW), now());
+  END IF;
+  RETURN NULL;
+END;
+$$;
+CREATE TRIGGER "characters_history" AFTER INSERT OR UPDATE OR DELETE ON "public"."characters" FOR EACH ROW EXECUTE FUNCTION "public"."record_character_history"();
+-- Record every version of "character_skills"
+CREATE FUNCTION "public"."record_character_skill_history"() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE "public"."character_skill_history" SET "valid_to" = now() WHERE "character_id" = OLD."character_id" AND "slot" = OLD."slot" AND "skill_id" = OLD."skill_id" AND "valid_to" = 'infinity';
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    INSERT INTO "public"."character_skill_history" ("character_id", "slot", "skill_id", "valid_from") VALUES (NEW."character_id", NEW."slot", NEW."skill_id", now());
+  END IF;
+  RETURN NULL;
+END;
+$$;
+CREATE TRIGGER "character_skills_history" AFTER INSERT OR UPDATE OR DELETE ON "public"."character_skills" FOR EACH ROW EXECUTE FUNCTION "public"."record_character_skill_history"();
+-- Backfill the current state
+INSERT INTO "public"."character_history" ("id", "snapshot", "valid_from") SELECT "id", to_jsonb(c), now() FROM "public"."characters" c;
+INSERT INTO "public"."character_skill_history" ("character_id", "slot", "skill_id", "valid_from") SELECT "character_id", "slot", "skill_id", now() FROM "public"."character_skills";
@@ -0,0 +1,4 @@
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ADD COLUMN "deleted_at" timestamptz NULL;
+-- Modify "skills" table
+ALTER TABLE "public"."skills" ADD COLUMN "deleted_at" timestamptz NULL;
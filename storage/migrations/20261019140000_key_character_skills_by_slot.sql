@@ -0,0 +1,4 @@
+-- Keep a single skill per slot
+DELETE FROM "public"."character_skills" a USING "public"."character_skills" b WHERE a."character_id" = b."character_id" AND a."slot" = b."slot" AND a."skill_id" > b."skill_id";
+-- Modify "character_skills" table
+ALTER TABLE "public"."character_skills" DROP CONSTRAINT "character_skills_pkey", ADD PRIMARY KEY ("character_id", "slot");
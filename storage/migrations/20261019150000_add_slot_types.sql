@@ -0,0 +1,11 @@
+-- Modify "skills" table
+ALTER TABLE "public"."skills" ADD COLUMN "slot_type" character varying(16) NOT NULL DEFAULT 'special', ADD CONSTRAINT "skills_slot_type_check" CHECK (slot_type IN ('normal', 'special', 'passive'));
+-- Modify "skill_revisions" table
+ALTER TABLE "public"."skill_revisions" ADD COLUMN "slot_type" character varying(16) NOT NULL DEFAULT 'special';
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ADD COLUMN "layout" jsonb NOT NULL DEFAULT '["normal", "special", "special", "special", "special"]';
+-- Normal attacks go in normal slots
+UPDATE "public"."skills" SET "slot_type" = 'normal' WHERE "reactor" -> 'tags' @> '[{"_kind": "label", "text": "NormalAttack"}]';
+UPDATE "public"."skill_revisions" SET "slot_type" = 'normal' WHERE "reactor" -> 'tags' @> '[{"_kind": "label", "text": "NormalAttack"}]';
+-- Past versions of characters had the default layout
+UPDATE "public"."character_history" SET "snapshot" = "snapshot" || '{"layout": ["normal", "special", "special", "special", "special"]}';
@@ -0,0 +1,4 @@
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ADD COLUMN "tags" jsonb NOT NULL DEFAULT '[]';
+-- Past versions of characters had no tags
+UPDATE "public"."character_history" SET "snapshot" = "snapshot" || '{"tags": []}';
@@ -0,0 +1,2 @@
+-- Create "tag_types" table
+CREATE TABLE "public"."tag_types" ("name" character varying(64) NOT NULL, "values" text[] NOT NULL, PRIMARY KEY ("name"));
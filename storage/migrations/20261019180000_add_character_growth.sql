@@ -0,0 +1,4 @@
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ADD COLUMN "growth" jsonb NOT NULL DEFAULT '{}';
+-- Past versions of characters did not grow
+UPDATE "public"."character_history" SET "snapshot" = "snapshot" || '{"growth": {}}';
@@ -0,0 +1,6 @@
+-- Create "items" table
+CREATE TABLE "public"."items" ("id" serial NOT NULL, "name" character varying(255) NOT NULL, "bonuses" jsonb NOT NULL DEFAULT '{}', "revision" integer NOT NULL DEFAULT 1, PRIMARY KEY ("id"));
+-- Create "item_skills" table
+CREATE TABLE "public"."item_skills" ("item_id" integer NOT NULL, "skill_id" integer NOT NULL, PRIMARY KEY ("item_id", "skill_id"), CONSTRAINT "item_skills_item_id_fkey" FOREIGN KEY ("item_id") REFERENCES "public"."items" ("id") ON UPDATE NO ACTION ON DELETE CASCADE, CONSTRAINT "item_skills_skill_id_fkey" FOREIGN KEY ("skill_id") REFERENCES "public"."skills" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
+-- Create "character_items" table
+CREATE TABLE "public"."character_items" ("character_id" integer NOT NULL, "item_id" integer NOT NULL, PRIMARY KEY ("character_id", "item_id"), CONSTRAINT "character_items_character_id_fkey" FOREIGN KEY ("character_id") REFERENCES "public"."characters" ("id") ON UPDATE NO ACTION ON DELETE CASCADE, CONSTRAINT "character_items_item_id_fkey" FOREIGN KEY ("item_id") REFERENCES "public"."items" ("id") ON UPDATE NO ACTION ON DELETE CASCADE);
@@ -0,0 +1,4 @@
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ALTER COLUMN "damage" DROP NOT NULL, ALTER COLUMN "defense" DROP NOT NULL, ALTER COLUMN "critical_odds" DROP NOT NULL, ALTER COLUMN "critical_loss" DROP NOT NULL, ALTER COLUMN "health" DROP NOT NULL, ALTER COLUMN "speed" DROP NOT NULL, ADD COLUMN "parent_id" integer NULL, ADD CONSTRAINT "characters_parent_id_fkey" FOREIGN KEY ("parent_id") REFERENCES "public"."characters" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION;
+-- Past versions of characters had no parent
+UPDATE "public"."character_history" SET "snapshot" = "snapshot" || '{"parent_id": null}';
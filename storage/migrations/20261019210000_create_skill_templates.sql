@@ -0,0 +1,4 @@
+-- Create "skill_templates" table
+CREATE TABLE "public"."skill_templates" ("id" serial NOT NULL, "name" character varying(255) NOT NULL, "slot_type" character varying(16) NOT NULL DEFAULT 'special', "reactor" jsonb NOT NULL, "params" jsonb NOT NULL DEFAULT '{}', "revision" integer NOT NULL DEFAULT 1, PRIMARY KEY ("id"), CONSTRAINT "skill_templates_slot_type_check" CHECK (slot_type IN ('normal', 'special', 'passive')));
+-- Modify "skills" table
+ALTER TABLE "public"."skills" ADD COLUMN "template_id" integer NULL, ADD COLUMN "params" jsonb NULL, ADD CONSTRAINT "skills_template_id_fkey" FOREIGN KEY ("template_id") REFERENCES "public"."skill_templates" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+-- Create index "skills_reactor_idx" to table: "skills"
+CREATE INDEX "skills_reactor_idx" ON "public"."skills" USING GIN ("reactor" jsonb_path_ops);
@@ -0,0 +1,4 @@
+-- Modify "skills" table
+ALTER TABLE "public"."skills" ADD COLUMN "reactor_hash" character varying(64) NOT NULL DEFAULT '';
+-- Create index "skills_reactor_hash_idx" to table: "skills"
+CREATE INDEX "skills_reactor_hash_idx" ON "public"."skills" ("reactor_hash");
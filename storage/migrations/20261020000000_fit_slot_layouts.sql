@@ -0,0 +1,24 @@
+-- Modify "characters" table
+ALTER TABLE "public"."characters" ALTER COLUMN "layout" DROP NOT NULL, ALTER COLUMN "layout" DROP DEFAULT;
+-- Characters holding skills which do not fit the layout they were given get
+-- one built from the slots they hold
+UPDATE "public"."characters" AS c SET "layout" = (
+    SELECT
+        jsonb_agg(COALESCE(s.slot_type, CASE WHEN i = 0 THEN 'normal' ELSE 'special' END) ORDER BY i)
+    FROM
+        generate_series(0, (SELECT max(slot) FROM "public"."character_skills" WHERE character_id = c.id)) AS i LEFT JOIN
+            "public"."character_skills" cs ON cs.character_id = c.id AND cs.slot = i LEFT JOIN
+            "public"."skills" s ON s.id = cs.skill_id
+)
+WHERE c.layout = '["normal", "special", "special", "special", "special"]' AND EXISTS (
+    SELECT
+        1
+    FROM
+        "public"."character_skills" cs JOIN
+            "public"."skills" s ON s.id = cs.skill_id
+    WHERE
+        cs.character_id = c.id AND s.slot_type <> CASE WHEN cs.slot = 0 THEN 'normal' ELSE 'special' END
+);
+-- The others follow the default layout of the rules, whatever their number of slots
+UPDATE "public"."characters" SET "layout" = NULL WHERE "layout" = '["normal", "special", "special", "special", "special"]';
+UPDATE "public"."character_history" SET "snapshot" = jsonb_set("snapshot", '{layout}', 'null') WHERE "snapshot" -> 'layout' = '["normal", "special", "special", "special", "special"]';
@@ -0,0 +1,15 @@
+-- List the verbs a reactor takes, leaving out those of the reactors its buffs carry
+CREATE FUNCTION "public"."reactor_verbs"("reactor" jsonb) RETURNS text[] LANGUAGE sql IMMUTABLE AS $$
+WITH RECURSIVE "node" ("value") AS (
+  SELECT "reactor"
+  UNION ALL
+  SELECT "child"."value" FROM "node", LATERAL (
+    SELECT "value" FROM jsonb_each(CASE WHEN jsonb_typeof("node"."value") = 'object' THEN "node"."value" - CASE WHEN "node"."value" ? '_verb' THEN 'reactor' ELSE '' END END)
+    UNION ALL
+    SELECT "value" FROM jsonb_array_elements(CASE WHEN jsonb_typeof("node"."value") = 'array' THEN "node"."value" END)
+  ) "child"
+)
+SELECT COALESCE(array_agg(DISTINCT "value" ->> '_verb'), '{}') FROM "node" WHERE jsonb_typeof("value") = 'object' AND "value" ? '_verb';
+$$;
+-- Create index "skills_reactor_verbs_idx" to table: "skills"
+CREATE INDEX "skills_reactor_verbs_idx" ON "public"."skills" USING GIN ((reactor_verbs("reactor")));
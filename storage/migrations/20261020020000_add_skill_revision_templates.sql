@@ -0,0 +1,4 @@
+-- Modify "skill_revisions" table
+ALTER TABLE "public"."skill_revisions" ADD COLUMN "template_id" integer NULL, ADD COLUMN "params" jsonb NULL, ADD CONSTRAINT "skill_revisions_template_id_fkey" FOREIGN KEY ("template_id") REFERENCES "public"."skill_templates" ("id") ON UPDATE NO ACTION ON DELETE SET NULL;
+-- Record the templates of the current revisions of derived skills
+UPDATE "public"."skill_revisions" r SET "template_id" = s."template_id", "params" = s."params" FROM "public"."skills" s WHERE r."skill_id" = s."id" AND r."revision" = s."revision" AND s."template_id" IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Children given a copy of the layout of their parent inherit it instead
+UPDATE "public"."characters" c SET "layout" = NULL FROM "public"."characters" p WHERE c."parent_id" = p."id" AND c."layout" = p."layout";
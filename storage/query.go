@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/lib/pq"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Query narrows down, orders and pages a listing. The zero value lists every
+// record in ID order. A non-zero AsOf lists the records as they were at that
+// time.
+type Query struct {
+	IDs   []int
+	Name  string
+	Sort  []Order
+	After string
+	Limit int
+	AsOf  time.Time
+}
+
+type Order struct {
+	Key  string
+	Desc bool
+}
+
+// StatRange keeps the characters whose stat compares to the value, as in
+// speed >= 10.
+type StatRange struct {
+	Stat       string
+	Comparator string
+	Value      int
+}
+
+type CharacterQuery struct {
+	Query
+	Stats []StatRange
+}
+
+// IndexRange keeps the skills with a tag whose index compares to the value,
+// as in priority >= 10.
+type IndexRange struct {
+	Comparator string
+	Value      int
+}
+
+// SkillQuery narrows skills down by their reactors: the text of a label, an
+// exclusion group, ranges of priority, all met by the same tag, the verbs
+// they take and the signals they respond to or count their lifecycles down
+// on, any of those listed. Only the reactors of the skills themselves count,
+// not those their buffs carry.
+type SkillQuery struct {
+	Query
+	Label          string
+	ExclusionGroup *int
+	Priority       []IndexRange
+	Verbs          []string
+	Signals        []string
+}
+
+var comparators = map[string]bool{
+	"=":  true,
+	"!=": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+}
+
+// compile renders the WHERE, ORDER BY and LIMIT clauses of the query with
+// sqlx.In style placeholders. The columns map the sort keys to their
+// expressions, and filters are extra conditions ANDed with the query's own.
+// One more row than the limit is asked for, which tells whether a next page
+// exists; the orders returned always end with the ID, so that cursors are
+// unambiguous.
+func (q Query) compile(columns map[string]string, filters []string, args []any) (string, []any, []Order, error) {
+	orders := make([]Order, 0, len(q.Sort)+1)
+	for _, order := range q.Sort {
+		if _, ok := columns[order.Key]; !ok {
+			return "", nil, nil, fmt.Errorf("%w: unknown sort key %q", ErrInvalidQuery, order.Key)
+		}
+		orders = append(orders, order)
+		if order.Key == "id" {
+			break
+		}
+	}
+	if len(orders) == 0 || orders[len(orders)-1].Key != "id" {
+		orders = append(orders, Order{Key: "id"})
+	}
+
+	if len(q.IDs) > 0 {
+		filters = append(filters, columns["id"]+" IN (?)")
+		args = append(args, q.IDs)
+	}
+	if q.Name != "" {
+		filters = append(filters, columns["name"]+" ILIKE ?")
+		args = append(args, "%"+escapeLike(q.Name)+"%")
+	}
+	if q.After != "" {
+		values, err := decodeCursor(q.After, orders)
+		if err != nil {
+			return "", nil, nil, err
+		}
+
+		var keyset []string
+		for i, order := range orders {
+			var terms []string
+			for _, o := range orders[:i] {
+				terms = append(terms, columns[o.Key]+" = ?")
+			}
+			args = append(args, values[:i]...)
+
+			comparator := ">"
+			if order.Desc {
+				comparator = "<"
+			}
+			terms = append(terms, columns[order.Key]+" "+comparator+" ?")
+			args = append(args, values[i])
+			keyset = append(keyset, "("+strings.Join(terms, " AND ")+")")
+		}
+		filters = append(filters, "("+strings.Join(keyset, " OR ")+")")
+	}
+
+	var clause strings.Builder
+	if len(filters) > 0 {
+		clause.WriteString(" WHERE ")
+		clause.WriteString(strings.Join(filters, " AND "))
+	}
+	clause.WriteString(" ORDER BY ")
+	for i, order := range orders {
+		if i > 0 {
+			clause.WriteString(", ")
+		}
+		clause.WriteString(columns[order.Key])
+		if order.Desc {
+			clause.WriteString(" DESC")
+		}
+	}
+	if q.Limit > 0 {
+		clause.WriteString(fmt.Sprintf(" LIMIT %d", q.Limit+1))
+	}
+
+	return clause.String(), args, orders, nil
+}
+
+// cursor is what the cursor of a page encodes: the sort keys it was taken
+// under, descending ones prefixed with a minus as in the sort parameter, and
+// the values of the last row for each of them.
+type cursor struct {
+	Sort   []string `json:"sort"`
+	Values []any    `json:"values"`
+}
+
+func sortKeys(orders []Order) []string {
+	keys := make([]string, len(orders))
+	for i, order := range orders {
+		keys[i] = order.Key
+		if order.Desc {
+			keys[i] = "-" + order.Key
+		}
+	}
+
+	return keys
+}
+
+// page trims the extra row asked for by compile and returns the cursor of
+// the next page, if any.
+func page[T any](q Query, rows []T, orders []Order, values func(T) map[string]any) ([]T, string, error) {
+	if q.Limit <= 0 || len(rows) <= q.Limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:q.Limit]
+	last := values(rows[len(rows)-1])
+	c := cursor{Sort: sortKeys(orders), Values: make([]any, len(orders))}
+	for i, order := range orders {
+		c.Values[i] = last[order.Key]
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rows, base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the values of the cursor, which must have been taken
+// under the same orders, or its keyset would skip or repeat rows.
+func decodeCursor(s string, orders []Order) ([]any, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || len(c.Values) != len(c.Sort) {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if !slices.Equal(c.Sort, sortKeys(orders)) {
+		return nil, fmt.Errorf("%w: cursor of another sort order", ErrInvalidQuery)
+	}
+	for i, v := range c.Values {
+		if f, ok := v.(float64); ok {
+			c.Values[i] = int64(f)
+		}
+	}
+
+	return c.Values, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (q CharacterQuery) compile(columns map[string]string) (string, []any, []Order, error) {
+	var filters []string
+	var args []any
+	for _, stat := range q.Stats {
+		column, ok := columns[stat.Stat]
+		if !ok || stat.Stat == "id" || stat.Stat == "name" {
+			return "", nil, nil, fmt.Errorf("%w: unknown stat %q", ErrInvalidQuery, stat.Stat)
+		}
+		if !comparators[stat.Comparator] {
+			return "", nil, nil, fmt.Errorf("%w: unknown comparator %q", ErrInvalidQuery, stat.Comparator)
+		}
+
+		filters = append(filters, column+" "+stat.Comparator+" ?")
+		args = append(args, stat.Value)
+	}
+
+	return q.Query.compile(columns, filters, args)
+}
+
+// compile filters skills by their reactors. Labels, exclusion groups and
+// signals are matched by jsonb containment at the paths they are found at,
+// which the GIN index over the reactors serves, as it does the equality on
+// the kind of the priority tag, though not its range. Verbs are matched
+// against reactor_verbs, which has a GIN index of its own and leaves out the
+// verbs of the reactors buffs carry.
+func (q SkillQuery) compile(columns map[string]string) (string, []any, []Order, error) {
+	var filters []string
+	var args []any
+	contains := func(doc any) {
+		b, _ := json.Marshal(doc)
+		filters = append(filters, "reactor @> ?::jsonb")
+		args = append(args, string(b))
+	}
+	matches := func(predicate string) {
+		filters = append(filters, "reactor @@ ?::jsonpath")
+		args = append(args, predicate)
+	}
+
+	if q.Label != "" {
+		contains(map[string]any{"tags": []any{map[string]any{"_kind": "label", "text": q.Label}}})
+	}
+	if q.ExclusionGroup != nil {
+		contains(map[string]any{"tags": []any{map[string]any{"_kind": "exclusion_group", "index": *q.ExclusionGroup}}})
+	}
+	if len(q.Priority) > 0 {
+		terms := []string{`@._kind == "priority"`}
+		for _, r := range q.Priority {
+			if !comparators[r.Comparator] {
+				return "", nil, nil, fmt.Errorf("%w: unknown comparator %q", ErrInvalidQuery, r.Comparator)
+			}
+			comparator := r.Comparator
+			if comparator == "=" {
+				comparator = "=="
+			}
+			terms = append(terms, fmt.Sprintf("@.index %s %d", comparator, r.Value))
+		}
+		matches("exists($.tags[*] ? (" + strings.Join(terms, " && ") + "))")
+	}
+	if len(q.Verbs) > 0 {
+		for _, verb := range q.Verbs {
+			if !slices.Contains(reactor.Verbs, verb) {
+				return "", nil, nil, fmt.Errorf("%w: unknown verb %q", ErrInvalidQuery, verb)
+			}
+		}
+		filters = append(filters, "reactor_verbs(reactor) && ?::text[]")
+		args = append(args, pq.StringArray(q.Verbs))
+	}
+	if len(q.Signals) > 0 {
+		var terms []string
+		for _, signal := range q.Signals {
+			if !slices.Contains(reactor.Signals, signal) {
+				return "", nil, nil, fmt.Errorf("%w: unknown signal %q", ErrInvalidQuery, signal)
+			}
+			trigger := map[string]any{"signal": signal}
+			for _, doc := range []map[string]any{
+				{"respond": map[string]any{"when": trigger}},
+				{"leading": map[string]any{"when": []any{trigger}}},
+				{"cooling": map[string]any{"when": []any{trigger}}},
+				{"capacity": map[string]any{"when": []any{trigger}}},
+			} {
+				b, _ := json.Marshal(doc)
+				terms = append(terms, "reactor @> ?::jsonb")
+				args = append(args, string(b))
+			}
+		}
+		filters = append(filters, "("+strings.Join(terms, " OR ")+")")
+	}
+
+	return q.Query.compile(columns, filters, args)
+}
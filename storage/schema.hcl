@@ -1,3 +1,79 @@
+table "character_history" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = integer
+  }
+  column "snapshot" {
+    null = false
+    type = jsonb
+  }
+  column "valid_from" {
+    null = false
+    type = timestamptz
+  }
+  column "valid_to" {
+    null    = false
+    type    = timestamptz
+    default = "infinity"
+  }
+  index "character_history_id_valid_from_idx" {
+    columns = [column.id, column.valid_from]
+  }
+}
+table "character_items" {
+  schema = schema.public
+  column "character_id" {
+    null = false
+    type = integer
+  }
+  column "item_id" {
+    null = false
+    type = integer
+  }
+  primary_key {
+    columns = [column.character_id, column.item_id]
+  }
+  foreign_key "character_items_character_id_fkey" {
+    columns     = [column.character_id]
+    ref_columns = [table.characters.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+  foreign_key "character_items_item_id_fkey" {
+    columns     = [column.item_id]
+    ref_columns = [table.items.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
+table "character_skill_history" {
+  schema = schema.public
+  column "character_id" {
+    null = false
+    type = integer
+  }
+  column "slot" {
+    null = false
+    type = smallint
+  }
+  column "skill_id" {
+    null = false
+    type = integer
+  }
+  column "valid_from" {
+    null = false
+    type = timestamptz
+  }
+  column "valid_to" {
+    null    = false
+    type    = timestamptz
+    default = "infinity"
+  }
+  index "character_skill_history_character_id_valid_from_idx" {
+    columns = [column.character_id, column.valid_from]
+  }
+}
 table "character_skills" {
   schema = schema.public
   column "character_id" {
@@ -13,7 +89,7 @@ table "character_skills" {
     type = integer
   }
   primary_key {
-    columns = [column.character_id, column.slot, column.skill_id]
+    columns = [column.character_id, column.slot]
   }
   foreign_key "characters_skills_character_id_fkey" {
     columns     = [column.character_id]
@@ -39,32 +115,207 @@ table "characters" {
     type = character_varying(255)
   }
   column "damage" {
-    null = false
+    null = true
     type = integer
   }
   column "defense" {
-    null = false
+    null = true
     type = integer
   }
   column "critical_odds" {
-    null = false
+    null = true
     type = integer
   }
   column "critical_loss" {
-    null = false
+    null = true
     type = integer
   }
   column "health" {
-    null = false
+    null = true
     type = integer
   }
   column "speed" {
+    null = true
+    type = integer
+  }
+  column "revision" {
+    null    = false
+    type    = integer
+    default = 1
+  }
+  column "deleted_at" {
+    null = true
+    type = timestamptz
+  }
+  column "layout" {
+    null = true
+    type = jsonb
+  }
+  column "tags" {
+    null    = false
+    type    = jsonb
+    default = "[]"
+  }
+  column "growth" {
+    null    = false
+    type    = jsonb
+    default = "{}"
+  }
+  column "parent_id" {
+    null = true
+    type = integer
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "characters_parent_id_fkey" {
+    columns     = [column.parent_id]
+    ref_columns = [table.characters.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+}
+table "item_skills" {
+  schema = schema.public
+  column "item_id" {
+    null = false
+    type = integer
+  }
+  column "skill_id" {
+    null = false
+    type = integer
+  }
+  primary_key {
+    columns = [column.item_id, column.skill_id]
+  }
+  foreign_key "item_skills_item_id_fkey" {
+    columns     = [column.item_id]
+    ref_columns = [table.items.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+  foreign_key "item_skills_skill_id_fkey" {
+    columns     = [column.skill_id]
+    ref_columns = [table.skills.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+}
+table "items" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = serial
+  }
+  column "name" {
+    null = false
+    type = character_varying(255)
+  }
+  column "bonuses" {
+    null    = false
+    type    = jsonb
+    default = "{}"
+  }
+  column "revision" {
+    null    = false
+    type    = integer
+    default = 1
+  }
+  primary_key {
+    columns = [column.id]
+  }
+}
+table "skill_revisions" {
+  schema = schema.public
+  column "skill_id" {
+    null = false
+    type = integer
+  }
+  column "revision" {
+    null = false
+    type = integer
+  }
+  column "name" {
     null = false
+    type = character_varying(255)
+  }
+  column "reactor" {
+    null = false
+    type = jsonb
+  }
+  column "author" {
+    null = false
+    type = character_varying(255)
+  }
+  column "created_at" {
+    null    = false
+    type    = timestamptz
+    default = sql("now()")
+  }
+  column "slot_type" {
+    null    = false
+    type    = character_varying(16)
+    default = "special"
+  }
+  column "template_id" {
+    null = true
     type = integer
   }
+  column "params" {
+    null = true
+    type = jsonb
+  }
+  primary_key {
+    columns = [column.skill_id, column.revision]
+  }
+  foreign_key "skill_revisions_skill_id_fkey" {
+    columns     = [column.skill_id]
+    ref_columns = [table.skills.column.id]
+    on_update   = NO_ACTION
+    on_delete   = CASCADE
+  }
+  foreign_key "skill_revisions_template_id_fkey" {
+    columns     = [column.template_id]
+    ref_columns = [table.skill_templates.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+}
+table "skill_templates" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = serial
+  }
+  column "name" {
+    null = false
+    type = character_varying(255)
+  }
+  column "slot_type" {
+    null    = false
+    type    = character_varying(16)
+    default = "special"
+  }
+  column "reactor" {
+    null = false
+    type = jsonb
+  }
+  column "params" {
+    null    = false
+    type    = jsonb
+    default = "{}"
+  }
+  column "revision" {
+    null    = false
+    type    = integer
+    default = 1
+  }
   primary_key {
     columns = [column.id]
   }
+  check "skill_templates_slot_type_check" {
+    expr = "((slot_type)::text = ANY ((ARRAY['normal'::character varying, 'special'::character varying, 'passive'::character varying])::text[]))"
+  }
 }
 table "skills" {
   schema = schema.public
@@ -80,9 +331,75 @@ table "skills" {
     null = false
     type = jsonb
   }
+  column "revision" {
+    null    = false
+    type    = integer
+    default = 1
+  }
+  column "deleted_at" {
+    null = true
+    type = timestamptz
+  }
+  column "slot_type" {
+    null    = false
+    type    = character_varying(16)
+    default = "special"
+  }
+  column "template_id" {
+    null = true
+    type = integer
+  }
+  column "params" {
+    null = true
+    type = jsonb
+  }
+  column "reactor_hash" {
+    null    = false
+    type    = character_varying(64)
+    default = ""
+  }
   primary_key {
     columns = [column.id]
   }
+  index "skills_reactor_hash_idx" {
+    columns = [column.reactor_hash]
+  }
+  index "skills_reactor_idx" {
+    type = GIN
+    on {
+      column = column.reactor
+      ops    = jsonb_path_ops
+    }
+  }
+  index "skills_reactor_verbs_idx" {
+    type = GIN
+    on {
+      expr = "reactor_verbs(reactor)"
+    }
+  }
+  foreign_key "skills_template_id_fkey" {
+    columns     = [column.template_id]
+    ref_columns = [table.skill_templates.column.id]
+    on_update   = NO_ACTION
+    on_delete   = SET_NULL
+  }
+  check "skills_slot_type_check" {
+    expr = "((slot_type)::text = ANY ((ARRAY['normal'::character varying, 'special'::character varying, 'passive'::character varying])::text[]))"
+  }
+}
+table "tag_types" {
+  schema = schema.public
+  column "name" {
+    null = false
+    type = character_varying(64)
+  }
+  column "values" {
+    null = false
+    type = sql("text[]")
+  }
+  primary_key {
+    columns = [column.name]
+  }
 }
 schema "public" {
   comment = "standard public schema"
@@ -1,28 +1,127 @@
 package storage
 
 import (
+	"container/list"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/farseeingnorthwest/battleground.go/reactor"
 	"github.com/farseeingnorthwest/playground/battlefield/v2"
 	"github.com/jmoiron/sqlx"
 )
 
 type SkillMeta struct {
-	ID   int
-	Name string
+	ID       int
+	Name     string
+	SlotType SlotType `db:"slot_type"`
 }
 
+// Skill is a skill with its reactor. The reactors of the skills read from
+// the database are compiled ones, shared by all the skills of the same hash,
+// and are not to be changed but replaced.
 type Skill struct {
 	SkillMeta
-	Reactor *Reactor
+	Reactor     *Reactor `db:"-"`
+	ReactorHash string   `db:"reactor_hash"`
+	Revision    int
+	DeletedAt   *time.Time `db:"deleted_at"`
+	TemplateID  *int       `db:"template_id"`
+	Params      ParamValues
+	Author      string `db:"-"`
+	storedReactor
+}
+
+// storedReactor is the reactor of a skill as read from the database, before
+// it is compiled.
+type storedReactor struct {
+	StoredReactor []byte `db:"reactor"`
+}
+
+// compile sets the reactor of the skill read from the database, from the
+// compiled reactors by its hash. A skill which has its reactor already, as
+// the one written, keeps it.
+func (s *Skill) compile() (err error) {
+	stored := s.StoredReactor
+	s.StoredReactor = nil
+	if stored == nil || s.Reactor != nil {
+		return nil
+	}
+
+	s.Reactor, err = compiled.get(s.ReactorHash, stored)
+	return
+}
+
+// getSkill is sqlx.Get for a skill, compiling its reactor.
+func getSkill(q sqlx.Queryer, skill *Skill, query string, args ...any) error {
+	if err := sqlx.Get(q, skill, query, args...); err != nil {
+		return err
+	}
+
+	return skill.compile()
+}
+
+// selectSkills is sqlx.Select for skills, compiling their reactors.
+func selectSkills(q sqlx.Queryer, skills *[]Skill, query string, args ...any) error {
+	if err := sqlx.Select(q, skills, query, args...); err != nil {
+		return err
+	}
+	for i := range *skills {
+		if err := (*skills)[i].compile(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Hash is the content hash of the reactor of the skill, the one stored with
+// it or else computed, as for skills rebuilt from their revisions.
+func (s Skill) Hash() (string, error) {
+	if s.ReactorHash != "" {
+		return s.ReactorHash, nil
+	}
+
+	return s.Reactor.Hash()
+}
+
+// SkillDependent is a character slot holding a skill.
+type SkillDependent struct {
+	CharacterID   int    `db:"character_id"`
+	CharacterName string `db:"character_name"`
+	Slot          int
+}
+
+// SkillRevision is a skill as it was at a revision. The template of a
+// derived skill is kept along with its parameters, unless it has been
+// deleted since.
+type SkillRevision struct {
+	SkillID    int `db:"skill_id"`
+	Revision   int
+	Name       string
+	SlotType   SlotType `db:"slot_type"`
+	Reactor    *Reactor
+	TemplateID *int `db:"template_id"`
+	Params     ParamValues
+	Author     string
+	CreatedAt  time.Time `db:"created_at"`
 }
 
 type Reactor battlefield.FatReactor
 
+// Value is the canonical form of the reactor, so that identical reactors are
+// stored the same.
 func (r *Reactor) Value() (driver.Value, error) {
-	return json.Marshal((*battlefield.FatReactor)(r))
+	doc, err := r.Document()
+	if err != nil {
+		return nil, err
+	}
+
+	return reactor.Canonical(doc)
 }
 
 func (r *Reactor) Scan(value interface{}) error {
@@ -35,15 +134,102 @@ func (r *Reactor) Scan(value interface{}) error {
 	if err := json.Unmarshal(j, &f); err != nil {
 		return err
 	}
+	if f.FatReactor == nil {
+		return errors.New("missing reactor")
+	}
 
 	*r = Reactor(*f.FatReactor)
 	return nil
 }
 
+// Hash is the content hash of the reactor, the SHA-256 of its canonical form.
+func (r *Reactor) Hash() (string, error) {
+	doc, err := r.Document()
+	if err != nil {
+		return "", err
+	}
+
+	return reactor.Hash(doc)
+}
+
+// compiledSize bounds the compiled reactors, the least recently used of
+// which are dropped beyond it.
+const compiledSize = 1024
+
+// compiled holds the reactors of the skills read from the database by their
+// content hashes, so that a reactor is decoded once for all the skills and
+// reads sharing it. A compiled reactor is never changed: battles Spawn forks
+// of it.
+var compiled = compiledReactors{
+	reactors: make(map[string]*list.Element),
+	recent:   list.New(),
+}
+
+type compiledReactors struct {
+	mu       sync.Mutex
+	reactors map[string]*list.Element
+	recent   *list.List
+}
+
+type compiledReactor struct {
+	hash    string
+	reactor *Reactor
+}
+
+// get takes the reactor of the hash from the compiled reactors, or decodes
+// the stored one. A reactor without a hash, as rebuilt from the revisions,
+// is decoded but not kept.
+func (c *compiledReactors) get(hash string, stored []byte) (*Reactor, error) {
+	if hash != "" {
+		c.mu.Lock()
+		e, ok := c.reactors[hash]
+		if ok {
+			c.recent.MoveToFront(e)
+		}
+		c.mu.Unlock()
+		if ok {
+			return e.Value.(compiledReactor).reactor, nil
+		}
+	}
+
+	r := new(Reactor)
+	if err := r.Scan(stored); err != nil {
+		return nil, err
+	}
+	if hash == "" {
+		return r, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.reactors[hash]; ok {
+		return e.Value.(compiledReactor).reactor, nil
+	}
+	c.reactors[hash] = c.recent.PushFront(compiledReactor{hash, r})
+	if c.recent.Len() > compiledSize {
+		e := c.recent.Back()
+		c.recent.Remove(e)
+		delete(c.reactors, e.Value.(compiledReactor).hash)
+	}
+
+	return r, nil
+}
+
 func (r *Reactor) Spawn() battlefield.Reactor {
 	return (*battlefield.FatReactor)(r).Fork(nil).(battlefield.Reactor)
 }
 
+// Document is the reactor decoded into plain JSON values, which is what
+// lints are run on.
+func (r *Reactor) Document() (any, error) {
+	b, err := json.Marshal((*battlefield.FatReactor)(r))
+	if err != nil {
+		return nil, err
+	}
+
+	return reactor.Unmarshal(b)
+}
+
 type SkillRepository struct {
 	db *sqlx.DB
 }
@@ -52,70 +238,370 @@ func NewSkillRepository(db *sqlx.DB) *SkillRepository {
 	return &SkillRepository{db: db}
 }
 
-func (r SkillRepository) Find(ids ...int) (skills []SkillMeta, err error) {
-	if len(ids) == 0 {
-		err = r.db.Select(&skills, "SELECT id, name FROM skills ORDER BY ID")
-		return
+var skillColumns = map[string]string{
+	"id":   "id",
+	"name": "name",
+}
+
+func skillValues(skill SkillMeta) map[string]any {
+	return map[string]any{
+		"id":   skill.ID,
+		"name": skill.Name,
+	}
+}
+
+func (r SkillRepository) Find(ids ...int) ([]SkillMeta, error) {
+	skills, _, err := r.Query(SkillQuery{Query: Query{IDs: ids}})
+	return skills, err
+}
+
+// Query returns a page of skills along with the cursor of the next page,
+// which is empty on the last one.
+func (r SkillRepository) Query(q SkillQuery) ([]SkillMeta, string, error) {
+	clause, args, orders, err := q.compile(skillColumns)
+	if err != nil {
+		return nil, "", err
 	}
 
-	query, args, err := sqlx.In("SELECT id, name FROM skills WHERE id IN (?) ORDER BY ID", ids)
+	source, sourceArgs := skillSource(q.AsOf)
+	query, args, err := sqlx.In("SELECT id, name, slot_type FROM "+source+clause, append(sourceArgs, args...)...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	err = r.db.Select(&skills, r.db.Rebind(query), args...)
-	return
+	var skills []SkillMeta
+	if err := r.db.Select(&skills, r.db.Rebind(query), args...); err != nil {
+		return nil, "", err
+	}
+
+	return page(q.Query, skills, orders, skillValues)
+}
+
+func (r SkillRepository) FindEx(ids ...int) ([]Skill, error) {
+	return r.FindExAsOf(time.Time{}, ids...)
 }
 
-func (r SkillRepository) FindEx(ids ...int) (skills []Skill, err error) {
+// FindExAsOf is FindEx against the skills as they were at the given time.
+func (r SkillRepository) FindExAsOf(asOf time.Time, ids ...int) (skills []Skill, err error) {
+	source, args := skillSource(asOf)
 	if len(ids) == 0 {
-		err = r.db.Select(&skills, "SELECT * FROM skills ORDER BY ID")
+		err = selectSkills(r.db, &skills, r.db.Rebind("SELECT * FROM "+source+" ORDER BY ID"), args...)
 		return
 	}
 
-	query, args, err := sqlx.In("SELECT * FROM skills WHERE id IN (?) ORDER BY ID", ids)
+	query, args, err := sqlx.In("SELECT * FROM "+source+" WHERE id IN (?) ORDER BY ID", append(args, ids)...)
 	if err != nil {
 		return nil, err
 	}
 
-	err = r.db.Select(&skills, r.db.Rebind(query), args...)
+	err = selectSkills(r.db, &skills, r.db.Rebind(query), args...)
 	return
 }
 
 func (r SkillRepository) Get(id int) (*Skill, error) {
 	var skill Skill
-	if err := r.db.Get(&skill, "SELECT * FROM skills WHERE id = $1", id); err != nil {
+	if err := getSkill(r.db, &skill, "SELECT * FROM skills WHERE id = $1 AND deleted_at IS NULL", id); err != nil {
 		return nil, err
 	}
 
 	return &skill, nil
 }
 
+// Create inserts the skill. A skill derived from a template has its reactor
+// rendered from the template with its parameters.
 func (r SkillRepository) Create(skill *Skill) error {
-	if err := r.db.Get(skill, "INSERT INTO skills (name, reactor) VALUES ($1, $2) RETURNING *", skill.Name, skill.Reactor); err != nil {
-		return err
-	}
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if err := renderSkill(tx, skill); err != nil {
+			return err
+		}
+		if err := setReactorHash(skill); err != nil {
+			return err
+		}
+		if err := getSkill(tx, skill, `
+INSERT INTO
+    skills (name, slot_type, reactor, reactor_hash, template_id, params)
+VALUES
+    ($1, $2, $3, $4, $5, $6)
+RETURNING *
+`,
+			skill.Name, skill.SlotType, skill.Reactor, skill.ReactorHash, skill.TemplateID, skill.Params); err != nil {
+			return err
+		}
 
-	return nil
+		return saveSkillRevision(tx, skill)
+	})
 }
 
+// Update overwrites the skill unless it has been changed since the revision
+// it carries, in which case ErrStale is returned. A zero revision skips the
+// check. A skill derived from a template has its reactor rendered from the
+// template, and one given without a template is no longer derived.
 func (r SkillRepository) Update(skill *Skill) error {
-	if err := r.db.Get(skill, "UPDATE skills SET name = $1, reactor = $2 WHERE id = $3 RETURNING *", skill.Name, skill.Reactor, skill.ID); err != nil {
-		return err
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if err := renderSkill(tx, skill); err != nil {
+			return err
+		}
+		if err := setReactorHash(skill); err != nil {
+			return err
+		}
+		err := getSkill(tx, skill, `
+UPDATE
+    skills
+SET
+    name = $1,
+    slot_type = $2,
+    reactor = $3,
+    reactor_hash = $4,
+    template_id = $5,
+    params = $6,
+    revision = revision + 1
+WHERE
+    id = $7 AND deleted_at IS NULL AND ($8 = 0 OR revision = $8)
+RETURNING *
+`,
+			skill.Name,
+			skill.SlotType,
+			skill.Reactor,
+			skill.ReactorHash,
+			skill.TemplateID,
+			skill.Params,
+			skill.ID,
+			skill.Revision,
+		)
+		if err != nil {
+			return checkRevision(tx, "skills", skill.ID, err)
+		}
+
+		return saveSkillRevision(tx, skill)
+	})
+}
+
+// Duplicates lists the skills other than the given one whose reactors have
+// the given content hash, that is the same skill under other names.
+func (r SkillRepository) Duplicates(id int, hash string) ([]SkillMeta, error) {
+	var skills []SkillMeta
+	if err := r.db.Select(&skills, `
+SELECT
+    id, name, slot_type
+FROM
+    skills
+WHERE
+    reactor_hash = $1 AND id != $2 AND deleted_at IS NULL
+ORDER BY
+    id
+`,
+		hash, id); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return skills, nil
+}
+
+// Rehash stores the reactors of the skills stored before reactors were
+// hashed in canonical form along with their hashes, and tells how many there
+// were. Revisions are left as they are, for the reactors mean the same.
+func (r SkillRepository) Rehash() (int, error) {
+	var skills []Skill
+	err := transact(r.db, func(tx *sqlx.Tx) error {
+		if err := selectSkills(tx, &skills, "SELECT * FROM skills WHERE reactor_hash = '' ORDER BY id FOR UPDATE"); err != nil {
+			return err
+		}
+		for i := range skills {
+			skill := &skills[i]
+			if err := setReactorHash(skill); err != nil {
+				return err
+			}
+			if _, err := tx.Exec("UPDATE skills SET reactor = $1, reactor_hash = $2 WHERE id = $3", skill.Reactor, skill.ReactorHash, skill.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(skills), nil
 }
 
-func (r SkillRepository) Delete(id int, force bool) error {
-	if force {
-		if _, err := r.db.Exec("DELETE FROM character_skills WHERE skill_id = $1", id); err != nil {
+// Delete moves the skill to the trash. A skill still held by a character
+// is not deleted and ErrInUse is returned, unless forced to. The slots
+// holding a skill in the trash look empty, but keep it until it is purged.
+func (r SkillRepository) Delete(id, revision int, force bool) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		var deleted int
+		err := tx.Get(&deleted, `
+UPDATE
+    skills
+SET
+    deleted_at = now()
+WHERE
+    id = $1 AND deleted_at IS NULL AND ($2 = 0 OR revision = $2)
+RETURNING id
+`,
+			id, revision)
+		if err != nil {
+			return checkRevision(tx, "skills", id, err)
+		}
+		if force {
+			return nil
+		}
+
+		var used bool
+		if err := tx.Get(&used, `
+SELECT EXISTS (
+    SELECT
+        1
+    FROM
+        character_skills s JOIN
+            characters c ON s.character_id = c.id
+    WHERE
+        skill_id = $1 AND c.deleted_at IS NULL
+)
+`,
+			id); err != nil {
 			return err
 		}
+		if used {
+			return fmt.Errorf("skills/%d: %w", id, ErrInUse)
+		}
+
+		return nil
+	})
+}
+
+// Trash lists the deleted skills, the most recently deleted first.
+func (r SkillRepository) Trash() ([]Skill, error) {
+	var skills []Skill
+	if err := selectSkills(r.db, &skills, "SELECT * FROM skills WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id"); err != nil {
+		return nil, err
+	}
+
+	return skills, nil
+}
+
+// Restore takes the skill out of the trash, back into the slots which kept
+// it, unless they have been given other skills since.
+func (r SkillRepository) Restore(id int) (*Skill, error) {
+	var skill Skill
+	if err := getSkill(r.db, &skill, "UPDATE skills SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL RETURNING *", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("trash/skills/%d: %w", id, ErrNotFound)
+		}
+		return nil, err
 	}
-	if _, err := r.db.Exec("DELETE FROM skills WHERE id = $1", id); err != nil {
+
+	return &skill, nil
+}
+
+// Purge deletes the skill in the trash for good, along with its revisions
+// and the slots still holding it.
+func (r SkillRepository) Purge(id int) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec("DELETE FROM character_skills WHERE skill_id = $1", id); err != nil {
+			return err
+		}
+
+		var purged int
+		err := tx.Get(&purged, "DELETE FROM skills WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("trash/skills/%d: %w", id, ErrNotFound)
+		}
+
 		return err
+	})
+}
+
+// Dependents lists the character slots holding the skill, that is the ones
+// a forced delete would empty, those of the children inheriting them
+// included. Children inherit the slots of their nearest ancestor having
+// slots of its own. Skills in the trash are not found, whether slots still
+// keep them or not.
+func (r SkillRepository) Dependents(id int) ([]SkillDependent, error) {
+	var exists bool
+	if err := r.db.Get(&exists, "SELECT EXISTS (SELECT 1 FROM skills WHERE id = $1 AND deleted_at IS NULL)", id); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("skills/%d: %w", id, ErrNotFound)
 	}
 
-	return nil
+	dependents := []SkillDependent{}
+	if err := r.db.Select(&dependents, `
+WITH RECURSIVE holders AS (
+    SELECT character_id, slot, 0 AS depth FROM character_skills WHERE skill_id = $1
+    UNION ALL
+    SELECT
+        c.id, h.slot, h.depth + 1
+    FROM
+        holders h JOIN
+            characters c ON c.parent_id = h.character_id
+    WHERE
+        h.depth < 64 AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                character_skills s JOIN
+                    skills ON s.skill_id = skills.id
+            WHERE
+                s.character_id = c.id AND skills.deleted_at IS NULL
+        )
+)
+SELECT
+    h.character_id, c.name AS character_name, h.slot
+FROM
+    holders h JOIN
+        characters c ON h.character_id = c.id
+WHERE
+    c.deleted_at IS NULL
+ORDER BY
+    character_id, slot
+`,
+		id); err != nil {
+		return nil, err
+	}
+
+	return dependents, nil
+}
+
+func (r SkillRepository) Revisions(id int) ([]SkillRevision, error) {
+	var revisions []SkillRevision
+	if err := r.db.Select(&revisions, `
+SELECT
+    skill_id, revision, name, slot_type, author, created_at
+FROM
+    skill_revisions
+WHERE
+    skill_id = $1
+ORDER BY
+    revision DESC
+`,
+		id); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (r SkillRepository) Revision(id, revision int) (*SkillRevision, error) {
+	var rev SkillRevision
+	if err := r.db.Get(&rev, "SELECT * FROM skill_revisions WHERE skill_id = $1 AND revision = $2", id, revision); err != nil {
+		return nil, err
+	}
+
+	return &rev, nil
+}
+
+func setReactorHash(skill *Skill) (err error) {
+	skill.ReactorHash, err = skill.Reactor.Hash()
+	return
+}
+
+func saveSkillRevision(tx *sqlx.Tx, skill *Skill) error {
+	_, err := tx.Exec(
+		"INSERT INTO skill_revisions (skill_id, revision, name, slot_type, reactor, template_id, params, author) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		skill.ID, skill.Revision, skill.Name, skill.SlotType, skill.Reactor, skill.TemplateID, skill.Params, skill.Author)
+
+	return err
 }
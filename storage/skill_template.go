@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/farseeingnorthwest/battleground.go/functional"
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/playground/battlefield/v2"
+	"github.com/jmoiron/sqlx"
+)
+
+// SkillTemplate is a reactor with typed placeholders for its parameters.
+// Skills are derived from it by giving values to the parameters, and are
+// rendered again whenever it changes.
+type SkillTemplate struct {
+	ID       int
+	Name     string
+	SlotType SlotType `db:"slot_type"`
+	Reactor  TemplateReactor
+	Params   TemplateParams
+	Revision int
+	Author   string `db:"-"`
+}
+
+// TemplateReactor is the JSON of a reactor with placeholders, which the
+// battlefield cannot decode until they are filled.
+type TemplateReactor json.RawMessage
+
+func (r TemplateReactor) Value() (driver.Value, error) {
+	return []byte(r), nil
+}
+
+func (r *TemplateReactor) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid argument")
+	}
+
+	*r = append((*r)[:0], j...)
+	return nil
+}
+
+func (r TemplateReactor) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return []byte("null"), nil
+	}
+
+	return r, nil
+}
+
+func (r *TemplateReactor) UnmarshalJSON(b []byte) error {
+	*r = append((*r)[:0], b...)
+	return nil
+}
+
+// TemplateParams declares the parameters of a template.
+type TemplateParams reactor.Params
+
+func (p TemplateParams) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(reactor.Params(p))
+}
+
+func (p *TemplateParams) Scan(value interface{}) error {
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid argument")
+	}
+
+	*p = nil
+	return decode(j, (*reactor.Params)(p))
+}
+
+// ParamValues are the values a skill gives to the parameters of its
+// template, by name.
+type ParamValues map[string]any
+
+func (v ParamValues) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(map[string]any(v))
+}
+
+func (v *ParamValues) Scan(value interface{}) error {
+	if value == nil {
+		*v = nil
+		return nil
+	}
+	j, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid argument")
+	}
+
+	*v = nil
+	return decode(j, (*map[string]any)(v))
+}
+
+// decode is json.Unmarshal keeping numbers as they are written.
+func decode(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// Render fills the placeholders of the template with the values, or the
+// defaults of the parameters, into a reactor which must conform to the
+// reactor schema. ErrTemplate is returned along with the problems found
+// otherwise.
+func (t SkillTemplate) Render(values ParamValues) (*Reactor, error) {
+	doc, err := reactor.Unmarshal(t.Reactor)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, problems := reactor.Render(doc, reactor.Params(t.Params), values)
+	if len(problems) == 0 {
+		problems = reactor.Validate(doc)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("skill_templates/%d: %w: %s", t.ID, ErrTemplate, strings.Join(problems, "; "))
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var f battlefield.FatReactorFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	return (*Reactor)(f.FatReactor), nil
+}
+
+// renderSkill renders the reactor of a skill derived from a template.
+// Skills of no template are left as they are.
+func renderSkill(tx *sqlx.Tx, skill *Skill) error {
+	if skill.TemplateID == nil {
+		skill.Params = nil
+		return nil
+	}
+
+	var t SkillTemplate
+	if err := tx.Get(&t, "SELECT * FROM skill_templates WHERE id = $1", *skill.TemplateID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("skill_templates/%d: %w: no such template", *skill.TemplateID, ErrTemplate)
+		}
+		return err
+	}
+	if skill.Params == nil {
+		skill.Params = ParamValues{}
+	}
+
+	var err error
+	skill.Reactor, err = t.Render(skill.Params)
+	return err
+}
+
+type SkillTemplateRepository struct {
+	db *sqlx.DB
+}
+
+func NewSkillTemplateRepository(db *sqlx.DB) *SkillTemplateRepository {
+	return &SkillTemplateRepository{db: db}
+}
+
+func (r SkillTemplateRepository) Find() ([]SkillTemplate, error) {
+	var templates []SkillTemplate
+	if err := r.db.Select(&templates, "SELECT * FROM skill_templates ORDER BY id"); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r SkillTemplateRepository) Get(id int) (*SkillTemplate, error) {
+	var t SkillTemplate
+	if err := r.db.Get(&t, "SELECT * FROM skill_templates WHERE id = $1", id); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (r SkillTemplateRepository) Create(t *SkillTemplate) error {
+	return r.db.Get(t, "INSERT INTO skill_templates (name, slot_type, reactor, params) VALUES ($1, $2, $3, $4) RETURNING *",
+		t.Name, t.SlotType, t.Reactor, t.Params)
+}
+
+// Update overwrites the template unless it has been changed since the
+// revision it carries, in which case ErrStale is returned, and renders the
+// skills derived from it again. A zero revision skips the check. Nothing is
+// changed if any of the skills no longer renders.
+func (r SkillTemplateRepository) Update(t *SkillTemplate) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		updated := *t
+		err := tx.Get(&updated, `
+UPDATE
+    skill_templates
+SET
+    name = $1,
+    slot_type = $2,
+    reactor = $3,
+    params = $4,
+    revision = revision + 1
+WHERE
+    id = $5 AND ($6 = 0 OR revision = $6)
+RETURNING *
+`,
+			t.Name,
+			t.SlotType,
+			t.Reactor,
+			t.Params,
+			t.ID,
+			t.Revision,
+		)
+		if err != nil {
+			return checkRowRevision(tx, "skill_templates", t.ID, err)
+		}
+
+		var skills []Skill
+		if err := selectSkills(tx, &skills, "SELECT * FROM skills WHERE template_id = $1 AND deleted_at IS NULL ORDER BY id FOR UPDATE", t.ID); err != nil {
+			return err
+		}
+		for i := range skills {
+			skill := &skills[i]
+			if skill.Reactor, err = updated.Render(skill.Params); err != nil {
+				return fmt.Errorf("skills/%d: %w", skill.ID, err)
+			}
+			skill.Author = t.Author
+			if err := rerenderSkill(tx, skill); err != nil {
+				return err
+			}
+		}
+
+		*t = updated
+		return nil
+	})
+}
+
+// Delete removes the template. A template still having skills derived from
+// it is not deleted and ErrInUse is returned, unless forced to, which leaves
+// the skills as they are rendered now.
+func (r SkillTemplateRepository) Delete(id, revision int, force bool) error {
+	return transact(r.db, func(tx *sqlx.Tx) error {
+		if !force {
+			var used bool
+			if err := tx.Get(&used, "SELECT EXISTS (SELECT 1 FROM skills WHERE template_id = $1 AND deleted_at IS NULL)", id); err != nil {
+				return err
+			}
+			if used {
+				return fmt.Errorf("skill_templates/%d: %w", id, ErrInUse)
+			}
+		}
+
+		var deleted int
+		err := tx.Get(&deleted, "DELETE FROM skill_templates WHERE id = $1 AND ($2 = 0 OR revision = $2) RETURNING id", id, revision)
+		if err != nil {
+			return checkRowRevision(tx, "skill_templates", id, err)
+		}
+
+		return nil
+	})
+}
+
+// Derived lists the skills derived from the template.
+func (r SkillTemplateRepository) Derived(id int) ([]Skill, error) {
+	var skills []Skill
+	if err := selectSkills(r.db, &skills, "SELECT * FROM skills WHERE template_id = $1 AND deleted_at IS NULL ORDER BY id", id); err != nil {
+		return nil, err
+	}
+	if len(skills) > 0 {
+		return skills, nil
+	}
+
+	var exists bool
+	if err := r.db.Get(&exists, "SELECT EXISTS (SELECT 1 FROM skill_templates WHERE id = $1)", id); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("skill_templates/%d: %w", id, ErrNotFound)
+	}
+
+	return skills, nil
+}
+
+// UpdateParams gives new parameter values to skills derived from the
+// template, by skill ID, and renders them again. Nothing is changed if any
+// of the skills is not derived from the template or does not render.
+func (r SkillTemplateRepository) UpdateParams(id int, params map[int]ParamValues, author string) ([]Skill, error) {
+	var skills []Skill
+	err := transact(r.db, func(tx *sqlx.Tx) error {
+		var t SkillTemplate
+		if err := tx.Get(&t, "SELECT * FROM skill_templates WHERE id = $1", id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("skill_templates/%d: %w", id, ErrNotFound)
+			}
+			return err
+		}
+
+		ids := functional.Keys(params)
+		sort.Ints(ids)
+		for _, skillID := range ids {
+			var skill Skill
+			err := getSkill(tx, &skill, "SELECT * FROM skills WHERE id = $1 AND template_id = $2 AND deleted_at IS NULL FOR UPDATE", skillID, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("skill_templates/%d/skills/%d: %w", id, skillID, ErrNotFound)
+			}
+			if err != nil {
+				return err
+			}
+
+			skill.Params = params[skillID]
+			if skill.Params == nil {
+				skill.Params = ParamValues{}
+			}
+			if skill.Reactor, err = t.Render(skill.Params); err != nil {
+				return fmt.Errorf("skills/%d: %w", skillID, err)
+			}
+			skill.Author = author
+			if err := rerenderSkill(tx, &skill); err != nil {
+				return err
+			}
+			skills = append(skills, skill)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return skills, nil
+}
+
+// rerenderSkill stores the newly rendered reactor and the parameters of a
+// derived skill as its next revision.
+func rerenderSkill(tx *sqlx.Tx, skill *Skill) error {
+	if err := setReactorHash(skill); err != nil {
+		return err
+	}
+	if err := getSkill(tx, skill, `
+UPDATE
+    skills
+SET
+    reactor = $1,
+    reactor_hash = $2,
+    params = $3,
+    revision = revision + 1
+WHERE
+    id = $4
+RETURNING *
+`,
+		skill.Reactor, skill.ReactorHash, skill.Params, skill.ID); err != nil {
+		return err
+	}
+
+	return saveSkillRevision(tx, skill)
+}
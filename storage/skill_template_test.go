@@ -0,0 +1,76 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	. "github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkillTemplateRepository_Derived(t *testing.T) {
+	loadFixtures(t)
+
+	templateID := 1
+	skill := Skill{
+		SkillMeta:  SkillMeta{Name: "Double Strike", SlotType: SlotSpecial},
+		TemplateID: &templateID,
+		Params:     ParamValues{"multiplier": 150, "targets": 2},
+	}
+	assert.NoError(t, NewSkillRepository(db).Create(&skill))
+
+	r := NewSkillTemplateRepository(db)
+	skills, err := r.Derived(1)
+	assert.NoError(t, err)
+	assert.Len(t, skills, 1)
+	assert.Equal(t, ParamValues{"multiplier": json.Number("150"), "targets": json.Number("2")}, skills[0].Params)
+
+	skills, err = r.UpdateParams(1, map[int]ParamValues{skill.ID: {"multiplier": 200}}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, skills[0].Revision)
+
+	rev, err := NewSkillRepository(db).Revision(skill.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &templateID, rev.TemplateID)
+	assert.Equal(t, ParamValues{"multiplier": json.Number("150"), "targets": json.Number("2")}, rev.Params)
+
+	_, err = r.UpdateParams(1, map[int]ParamValues{skill.ID: {}}, "")
+	assert.ErrorIs(t, err, ErrTemplate)
+	_, err = r.UpdateParams(1, map[int]ParamValues{1: {"multiplier": 200}}, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = r.Derived(2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSkillTemplateRepository_Update(t *testing.T) {
+	loadFixtures(t)
+
+	templateID := 1
+	skill := Skill{
+		SkillMeta:  SkillMeta{Name: "Strike", SlotType: SlotSpecial},
+		TemplateID: &templateID,
+		Params:     ParamValues{"multiplier": 150},
+	}
+	assert.NoError(t, NewSkillRepository(db).Create(&skill))
+
+	r := NewSkillTemplateRepository(db)
+	template, err := r.Get(1)
+	assert.NoError(t, err)
+
+	template.Params["power"] = reactor.Param{Type: "integer"}
+	assert.ErrorIs(t, r.Update(template), ErrTemplate)
+
+	delete(template.Params, "power")
+	template.Name = "Heavy Strike"
+	assert.NoError(t, r.Update(template))
+	assert.Equal(t, 2, template.Revision)
+
+	skills, err := r.Derived(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, skills[0].Revision)
+
+	assert.ErrorIs(t, r.Delete(1, 0, false), ErrInUse)
+	assert.NoError(t, r.Delete(1, 2, true))
+}
@@ -13,8 +13,8 @@ func TestSkillRepository_Find(t *testing.T) {
 		ids    []int
 		skills []SkillMeta
 	}{
-		{nil, []SkillMeta{{1, "Normal Attack"}, {2, "Sleep"}}},
-		{[]int{1}, []SkillMeta{{1, "Normal Attack"}}},
+		{nil, []SkillMeta{{ID: 1, Name: "Normal Attack", SlotType: SlotNormal}, {ID: 2, Name: "Sleep", SlotType: SlotSpecial}}},
+		{[]int{1}, []SkillMeta{{ID: 1, Name: "Normal Attack", SlotType: SlotNormal}}},
 	} {
 		t.Run("", func(t *testing.T) {
 			loadFixtures(t)
@@ -28,6 +28,88 @@ func TestSkillRepository_Find(t *testing.T) {
 	}
 }
 
+func TestSkillRepository_Query(t *testing.T) {
+	group := 0
+	normalAttack := SkillMeta{ID: 1, Name: "Normal Attack", SlotType: SlotNormal}
+	sleep := SkillMeta{ID: 2, Name: "Sleep", SlotType: SlotSpecial}
+	for _, tt := range []struct {
+		query  SkillQuery
+		skills []SkillMeta
+		next   bool
+	}{
+		{SkillQuery{Query: Query{Name: "sle"}}, []SkillMeta{sleep}, false},
+		{SkillQuery{Query: Query{Sort: []Order{{Key: "name", Desc: true}}}}, []SkillMeta{sleep, normalAttack}, false},
+		{SkillQuery{Query: Query{IDs: []int{1, 2}, Limit: 1}}, []SkillMeta{normalAttack}, true},
+		{SkillQuery{Label: "Sleep"}, []SkillMeta{sleep}, false},
+		{SkillQuery{ExclusionGroup: &group}, []SkillMeta{normalAttack, sleep}, false},
+		{SkillQuery{Priority: []IndexRange{{">=", 5}, {"<", 20}}}, []SkillMeta{sleep}, false},
+		{SkillQuery{Priority: []IndexRange{{">", 10}}}, nil, false},
+		{SkillQuery{Verbs: []string{"attack", "heal"}}, []SkillMeta{normalAttack}, false},
+		{SkillQuery{Signals: []string{"round_end", "launch"}}, []SkillMeta{normalAttack, sleep}, false},
+		{SkillQuery{Signals: []string{"post_action"}}, []SkillMeta{sleep}, false},
+	} {
+		t.Run("", func(t *testing.T) {
+			loadFixtures(t)
+
+			r := NewSkillRepository(db)
+			skills, next, err := r.Query(tt.query)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.skills, skills)
+			assert.Equal(t, tt.next, next != "")
+		})
+	}
+}
+
+func TestSkillRepository_Query_Buff(t *testing.T) {
+	loadFixtures(t)
+
+	var id int
+	assert.NoError(t, db.Get(&id, `
+INSERT INTO
+    skills (name, slot_type, reactor)
+VALUES
+    ('Blessing', 'special', $1)
+RETURNING id
+`,
+		`{
+  "respond": {
+    "when": {"signal": "launch"},
+    "then": {"_kind": "verb", "verb": {"_verb": "buff", "reactor": {
+      "respond": {"when": {"signal": "round_start"}, "then": {"_kind": "verb", "verb": {"_verb": "heal"}}}
+    }}}
+  }
+}`))
+	blessing := SkillMeta{ID: id, Name: "Blessing", SlotType: SlotSpecial}
+
+	r := NewSkillRepository(db)
+	for _, tt := range []struct {
+		query  SkillQuery
+		skills []SkillMeta
+	}{
+		{SkillQuery{Verbs: []string{"buff"}}, []SkillMeta{blessing}},
+		{SkillQuery{Verbs: []string{"heal"}}, nil},
+		{SkillQuery{Signals: []string{"round_start"}}, nil},
+	} {
+		skills, _, err := r.Query(tt.query)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.skills, skills)
+	}
+}
+
+func TestSkillRepository_Query_Invalid(t *testing.T) {
+	r := NewSkillRepository(db)
+	for _, q := range []SkillQuery{
+		{Verbs: []string{"smite"}},
+		{Signals: []string{"turn_end"}},
+		{Priority: []IndexRange{{"~", 1}}},
+		{Query: Query{After: "eyJzb3J0IjpbIi1pZCJdLCJ2YWx1ZXMiOlsxXX0"}},
+	} {
+		_, _, err := r.Query(q)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+	}
+}
+
 func TestSkillRepository_FindEx(t *testing.T) {
 	loadFixtures(t)
 
@@ -73,6 +155,54 @@ func TestSkillRepository_Create(t *testing.T) {
 	assert.Contains(t, skill.Reactor.Tags(), b.Label("Taunt"))
 }
 
+func TestSkillRepository_Duplicates(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	n, err := r.Rehash()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	normalAttack, err := r.Get(1)
+	assert.NoError(t, err)
+	assert.Len(t, normalAttack.ReactorHash, 64)
+
+	basicAttack := Skill{
+		SkillMeta: SkillMeta{
+			Name:     "Basic Attack",
+			SlotType: SlotNormal,
+		},
+		Reactor: normalAttack.Reactor,
+	}
+	assert.NoError(t, r.Create(&basicAttack))
+	assert.Equal(t, normalAttack.ReactorHash, basicAttack.ReactorHash)
+
+	skills, err := r.Duplicates(basicAttack.ID, basicAttack.ReactorHash)
+	assert.NoError(t, err)
+	assert.Equal(t, []SkillMeta{normalAttack.SkillMeta}, skills)
+}
+
+func TestSkillRepository_Get_Compiled(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	_, err := r.Rehash()
+	assert.NoError(t, err)
+
+	skill, err := r.Get(1)
+	assert.NoError(t, err)
+	skills, err := r.FindEx(1)
+	assert.NoError(t, err)
+	assert.Same(t, skill.Reactor, skills[0].Reactor)
+
+	before, err := skill.Reactor.Document()
+	assert.NoError(t, err)
+	skill.Reactor.Spawn()
+	after, err := skill.Reactor.Document()
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
 func TestSkillRepository_Update(t *testing.T) {
 	loadFixtures(t)
 
@@ -95,6 +225,56 @@ func TestSkillRepository_Update(t *testing.T) {
 	assert.Contains(t, skill.Reactor.Tags(), b.Label("Taunt"))
 }
 
+func TestSkillRepository_Update_Stale(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	skill, err := r.Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, skill.Revision)
+
+	skill.Name = "Deep Sleep"
+	assert.NoError(t, r.Update(skill))
+	assert.Equal(t, 2, skill.Revision)
+
+	skill.Revision = 1
+	assert.ErrorIs(t, r.Update(skill), ErrStale)
+	assert.ErrorIs(t, r.Delete(2, 1, false), ErrStale)
+	assert.NoError(t, r.Delete(2, 2, false))
+}
+
+func TestSkillRepository_Revisions(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	skill := Skill{
+		SkillMeta: SkillMeta{
+			Name: "Taunt",
+		},
+		Reactor: (*Reactor)(b.NewFatReactor(
+			b.FatTags(b.Label("Taunt")),
+		)),
+		Author: "oda",
+	}
+	assert.NoError(t, r.Create(&skill))
+
+	skill.Name = "Provoke"
+	skill.Author = "ueno"
+	assert.NoError(t, r.Update(&skill))
+
+	revisions, err := r.Revisions(skill.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Revision)
+	assert.Equal(t, "ueno", revisions[0].Author)
+	assert.Equal(t, "oda", revisions[1].Author)
+
+	rev, err := r.Revision(skill.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Taunt", rev.Name)
+	assert.Contains(t, rev.Reactor.Tags(), b.Label("Taunt"))
+}
+
 func TestSkillRepository_Delete(t *testing.T) {
 	for _, tt := range []struct {
 		id    int
@@ -110,11 +290,11 @@ func TestSkillRepository_Delete(t *testing.T) {
 			loadFixtures(t)
 
 			r := NewSkillRepository(db)
-			err := r.Delete(tt.id, tt.force)
+			err := r.Delete(tt.id, 0, tt.force)
 			if tt.ok {
 				assert.NoError(t, err)
 			} else {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, ErrInUse)
 			}
 
 			skills, err := r.Find()
@@ -123,3 +303,100 @@ func TestSkillRepository_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestSkillRepository_Restore_Slots(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	characters := NewCharacterRepository(db)
+	assert.NoError(t, r.Delete(1, 0, true))
+
+	oda, err := characters.Get(1)
+	assert.NoError(t, err)
+	assert.Empty(t, oda.Skills)
+
+	oda.Health = 120
+	assert.NoError(t, characters.Update(oda))
+
+	_, err = r.Restore(1)
+	assert.NoError(t, err)
+	oda, err = characters.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]SkillMeta{
+		0: {ID: 1, Name: "Normal Attack", SlotType: SlotNormal},
+	}, oda.Skills)
+}
+
+func TestSkillRepository_Dependents(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	dependents, err := r.Dependents(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []SkillDependent{
+		{CharacterID: 1, CharacterName: "Oda", Slot: 0},
+	}, dependents)
+
+	dependents, err = r.Dependents(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []SkillDependent{}, dependents)
+
+	_, err = r.Dependents(3)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSkillRepository_Dependents_Trash(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	assert.NoError(t, r.Delete(1, 0, true))
+	_, err := r.Dependents(1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, r.Delete(2, 0, false))
+	_, err = r.Dependents(2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSkillRepository_Dependents_Inherited(t *testing.T) {
+	loadFixtures(t)
+
+	parentID := 1
+	child := &Character{
+		Name:      "Oda Jr.",
+		ParentID:  &parentID,
+		Inherited: []string{"damage", "defense", "critical_odds", "critical_loss", "health", "speed", "layout", "growth", "skills"},
+	}
+	assert.NoError(t, NewCharacterRepository(db).Create(child))
+
+	r := NewSkillRepository(db)
+	dependents, err := r.Dependents(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []SkillDependent{
+		{CharacterID: 1, CharacterName: "Oda", Slot: 0},
+		{CharacterID: child.ID, CharacterName: "Oda Jr.", Slot: 0},
+	}, dependents)
+}
+
+func TestSkillRepository_Trash(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewSkillRepository(db)
+	assert.NoError(t, r.Delete(2, 0, false))
+
+	trash, err := r.Trash()
+	assert.NoError(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, "Sleep", trash[0].Name)
+	}
+	_, err = r.Get(2)
+	assert.Error(t, err)
+
+	skill, err := r.Restore(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sleep", skill.Name)
+
+	assert.NoError(t, r.Delete(2, 0, false))
+	assert.NoError(t, r.Purge(2))
+	assert.ErrorIs(t, r.Purge(2), ErrNotFound)
+}
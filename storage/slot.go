@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// SlotType tells which slots a skill fits in.
+type SlotType string
+
+const (
+	SlotNormal  SlotType = "normal"
+	SlotSpecial SlotType = "special"
+	SlotPassive SlotType = "passive"
+)
+
+func (t SlotType) Valid() bool {
+	switch t {
+	case SlotNormal, SlotSpecial, SlotPassive:
+		return true
+	default:
+		return false
+	}
+}
+
+// SlotLayout gives the type of every skill slot of a character, indexed by
+// slot. Characters not given one, whose layout is nil, have the default
+// layout of the rules.
+type SlotLayout []SlotType
+
+func (l SlotLayout) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	return json.Marshal([]SlotType(l))
+}
+
+func (l *SlotLayout) Scan(value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, (*[]SlotType)(l))
+	default:
+		return errors.New("invalid argument")
+	}
+}
@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/yaml.v3"
+)
+
+type SyncAction string
+
+const (
+	// SyncPush copies a record changed in the directory into the database.
+	SyncPush SyncAction = "push"
+	// SyncPull copies a record changed in the database into the directory.
+	SyncPull SyncAction = "pull"
+	// SyncConflict marks a record changed on both sides since the last sync.
+	SyncConflict SyncAction = "conflict"
+)
+
+type SyncChange struct {
+	Table   string
+	ID      int
+	Action  SyncAction
+	Deleted bool
+}
+
+// syncState remembers the content hash of every record as of the last sync,
+// which tells a one-sided change apart from a conflicting one.
+type syncState struct {
+	Characters map[int]string `yaml:"characters"`
+	Skills     map[int]string `yaml:"skills"`
+}
+
+const syncStateFile = ".sync.yml"
+
+type Syncer struct {
+	db  *sqlx.DB
+	dir *Directory
+}
+
+func NewSyncer(db *sqlx.DB, dir *Directory) *Syncer {
+	return &Syncer{db: db, dir: dir}
+}
+
+// Sync reconciles the directory with the database. Records changed on one
+// side only are copied over to the other one, while records changed on both
+// are reported as conflicts and left untouched. With dryRun, nothing is
+// written and the returned changes describe what would have happened.
+func (s Syncer) Sync(dryRun bool) ([]SyncChange, error) {
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	dbSkills, err := NewSkillRepository(s.db).FindEx()
+	if err != nil {
+		return nil, err
+	}
+	dbCharacters, err := NewCharacterRepository(s.db).Find()
+	if err != nil {
+		return nil, err
+	}
+	fileSkills, err := s.dir.skillDocuments()
+	if err != nil {
+		return nil, err
+	}
+	fileCharacters, err := s.dir.characterDocuments()
+	if err != nil {
+		return nil, err
+	}
+
+	skillsInDB := make(map[int]*Skill, len(dbSkills))
+	skillHashesInDB := make(map[int]string, len(dbSkills))
+	for i := range dbSkills {
+		skill := &dbSkills[i]
+		if skillHashesInDB[skill.ID], err = skillHash(skill); err != nil {
+			return nil, err
+		}
+		skillsInDB[skill.ID] = skill
+	}
+	skillsInDir := make(map[int]*Skill, len(fileSkills))
+	skillHashesInDir := make(map[int]string, len(fileSkills))
+	for _, doc := range fileSkills {
+		skill, err := doc.skill()
+		if err != nil {
+			return nil, err
+		}
+		if skillHashesInDir[skill.ID], err = skillHash(&skill); err != nil {
+			return nil, err
+		}
+		skillsInDir[skill.ID] = &skill
+	}
+
+	charactersInDB := make(map[int]characterDocument, len(dbCharacters))
+	characterHashesInDB := make(map[int]string, len(dbCharacters))
+	for i := range dbCharacters {
+		doc := newCharacterDocument(&dbCharacters[i])
+		charactersInDB[doc.ID] = doc
+		characterHashesInDB[doc.ID] = documentHash(doc)
+	}
+	charactersInDir := make(map[int]characterDocument, len(fileCharacters))
+	characterHashesInDir := make(map[int]string, len(fileCharacters))
+	for _, doc := range fileCharacters {
+		charactersInDir[doc.ID] = doc
+		characterHashesInDir[doc.ID] = documentHash(doc)
+	}
+
+	skillChanges := reconcile("skills", skillHashesInDir, skillHashesInDB, state.Skills)
+	characterChanges := reconcile("characters", characterHashesInDir, characterHashesInDB, state.Characters)
+	changes := append(skillChanges, characterChanges...)
+	if dryRun {
+		return changes, nil
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, change := range skillChanges {
+		if change.Action == SyncPush && !change.Deleted {
+			if err := upsertSkill(tx, skillsInDir[change.ID]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, change := range parentsFirst(characterChanges, charactersInDir) {
+		if change.Action != SyncPush {
+			continue
+		}
+		if change.Deleted {
+			err = deleteCharacter(tx, change.ID)
+		} else {
+			err = upsertCharacter(tx, charactersInDir[change.ID])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range skillChanges {
+		if change.Action == SyncPush && change.Deleted {
+			if err := deleteSkill(tx, change.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, table := range []string{"skills", "characters"} {
+		if _, err := tx.Exec("SELECT setval(pg_get_serial_sequence($1, 'id'), COALESCE(MAX(id), 0) + 1, false) FROM "+table, table); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		if change.Action != SyncPull {
+			continue
+		}
+		switch {
+		case change.Deleted:
+			err = s.dir.remove(change.Table, change.ID)
+		case change.Table == "skills":
+			var doc skillDocument
+			if doc, err = newSkillDocument(skillsInDB[change.ID]); err == nil {
+				err = s.dir.write(change.Table, change.ID, doc)
+			}
+		default:
+			err = s.dir.write(change.Table, change.ID, charactersInDB[change.ID])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state.Skills = settle(skillChanges, skillHashesInDir, skillHashesInDB, state.Skills)
+	state.Characters = settle(characterChanges, characterHashesInDir, characterHashesInDB, state.Characters)
+	if err := s.saveState(state); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (s Syncer) loadState() (*syncState, error) {
+	state := &syncState{
+		Characters: make(map[int]string),
+		Skills:     make(map[int]string),
+	}
+
+	b, err := os.ReadFile(filepath.Join(s.dir.root, syncStateFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (s Syncer) saveState(state *syncState) error {
+	b, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir.root, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(s.dir.root, syncStateFile), b, 0o644)
+}
+
+// reconcile compares the hashes of both sides against the ones recorded by
+// the last sync. A missing hash stands for a missing record.
+func reconcile(table string, dir, db, base map[int]string) []SyncChange {
+	ids := make(map[int]struct{})
+	for _, m := range []map[int]string{dir, db, base} {
+		for id := range m {
+			ids[id] = struct{}{}
+		}
+	}
+
+	var changes []SyncChange
+	for id := range ids {
+		f, d, b := dir[id], db[id], base[id]
+		if f == d {
+			continue
+		}
+
+		switch {
+		case f != b && d == b:
+			changes = append(changes, SyncChange{table, id, SyncPush, f == ""})
+		case d != b && f == b:
+			changes = append(changes, SyncChange{table, id, SyncPull, d == ""})
+		default:
+			changes = append(changes, SyncChange{table, id, SyncConflict, false})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes
+}
+
+// settle computes the hashes to remember after the changes were applied.
+// Conflicting records keep their previous hash so that they stay in conflict
+// until one side is reverted or both agree.
+func settle(changes []SyncChange, dir, db, base map[int]string) map[int]string {
+	state := make(map[int]string)
+	for id, h := range dir {
+		if db[id] == h {
+			state[id] = h
+		}
+	}
+	for _, change := range changes {
+		var h string
+		switch change.Action {
+		case SyncPush:
+			h = dir[change.ID]
+		case SyncPull:
+			h = db[change.ID]
+		default:
+			h = base[change.ID]
+		}
+		if h != "" {
+			state[change.ID] = h
+		}
+	}
+
+	return state
+}
+
+func documentHash(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func skillHash(skill *Skill) (string, error) {
+	reactor, err := skill.Reactor.Value()
+	if err != nil {
+		return "", err
+	}
+
+	return documentHash(map[string]any{
+		"id":        skill.ID,
+		"name":      skill.Name,
+		"slot_type": skill.SlotType,
+		"reactor":   json.RawMessage(reactor.([]byte)),
+	}), nil
+}
+
+func upsertSkill(tx *sqlx.Tx, skill *Skill) error {
+	if err := setReactorHash(skill); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+INSERT INTO
+    skills (id, name, slot_type, reactor, reactor_hash)
+VALUES
+    ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    slot_type = excluded.slot_type,
+    reactor = excluded.reactor,
+    reactor_hash = excluded.reactor_hash,
+    revision = skills.revision + 1,
+    deleted_at = NULL
+`,
+		skill.ID,
+		skill.Name,
+		skill.SlotType,
+		skill.Reactor,
+		skill.ReactorHash,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO
+    skill_revisions (skill_id, revision, name, slot_type, reactor, template_id, params, author)
+SELECT
+    id, revision, name, slot_type, reactor, template_id, params, 'sync'
+FROM
+    skills
+WHERE
+    id = $1
+`,
+		skill.ID,
+	)
+
+	return err
+}
+
+// parentsFirst orders the character changes so that parents pushed along
+// with their children are written before them.
+func parentsFirst(changes []SyncChange, docs map[int]characterDocument) []SyncChange {
+	depth := func(id int) int {
+		var n int
+		for seen := map[int]bool{}; docs[id].Parent != nil && !seen[id]; n++ {
+			seen[id] = true
+			id = *docs[id].Parent
+		}
+		return n
+	}
+
+	ordered := append([]SyncChange(nil), changes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return depth(ordered[i].ID) < depth(ordered[j].ID)
+	})
+
+	return ordered
+}
+
+func upsertCharacter(tx *sqlx.Tx, doc characterDocument) error {
+	if _, err := tx.Exec(`
+INSERT INTO
+    characters (id, name, damage, defense, critical_odds, critical_loss, health, speed, layout, tags, growth, parent_id)
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (id) DO UPDATE SET
+    name = excluded.name,
+    damage = excluded.damage,
+    defense = excluded.defense,
+    critical_odds = excluded.critical_odds,
+    critical_loss = excluded.critical_loss,
+    health = excluded.health,
+    speed = excluded.speed,
+    layout = excluded.layout,
+    tags = excluded.tags,
+    growth = excluded.growth,
+    parent_id = excluded.parent_id,
+    revision = characters.revision + 1,
+    deleted_at = NULL
+`,
+		doc.ID,
+		doc.Name,
+		doc.Damage,
+		doc.Defense,
+		doc.CriticalOdds,
+		doc.CriticalLoss,
+		doc.Health,
+		doc.Speed,
+		doc.Layout,
+		doc.Tags,
+		doc.Growth,
+		doc.Parent,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM character_skills WHERE character_id = $1", doc.ID); err != nil {
+		return err
+	}
+	for slot, skill := range doc.Skills {
+		if _, err := tx.Exec(
+			"INSERT INTO character_skills (character_id, slot, skill_id) VALUES ($1, $2, $3)",
+			doc.ID, slot, skill); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteCharacter and deleteSkill move records removed from the directory to
+// the trash, as the API does.
+func deleteCharacter(tx *sqlx.Tx, id int) error {
+	_, err := tx.Exec("UPDATE characters SET deleted_at = now() WHERE id = $1", id)
+	return err
+}
+
+func deleteSkill(tx *sqlx.Tx, id int) error {
+	_, err := tx.Exec("UPDATE skills SET deleted_at = now() WHERE id = $1", id)
+	return err
+}
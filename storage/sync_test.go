@@ -0,0 +1,50 @@
+package storage_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncer_Sync(t *testing.T) {
+	loadFixtures(t)
+
+	dir := NewDirectory(t.TempDir())
+	s := NewSyncer(db, dir)
+	changes, err := s.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []SyncChange{
+		{"skills", 1, SyncPull, false},
+		{"skills", 2, SyncPull, false},
+		{"characters", 1, SyncPull, false},
+		{"characters", 2, SyncPull, false},
+	}, changes)
+
+	changes, err = s.Sync(false)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	files := NewFileCharacterRepository(dir)
+	ueno, err := files.Get(2)
+	assert.NoError(t, err)
+	ueno.Speed = 12
+	assert.NoError(t, files.Update(ueno))
+
+	changes, err = s.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []SyncChange{{"characters", 2, SyncPush, false}}, changes)
+
+	character, err := NewCharacterRepository(db).Get(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, character.Speed)
+
+	ueno.Speed = 13
+	assert.NoError(t, files.Update(ueno))
+	_, err = db.Exec("UPDATE characters SET speed = 14 WHERE id = 2")
+	assert.NoError(t, err)
+
+	changes, err = s.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []SyncChange{{"characters", 2, SyncConflict, false}}, changes)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// TagType is an enumerated tag type defined by content, such as a faction,
+// whose tags take one of its values.
+type TagType struct {
+	Name   string
+	Values pq.StringArray
+}
+
+type TagTypeRepository struct {
+	db *sqlx.DB
+}
+
+func NewTagTypeRepository(db *sqlx.DB) *TagTypeRepository {
+	return &TagTypeRepository{db: db}
+}
+
+func (r TagTypeRepository) List() ([]TagType, error) {
+	var types []TagType
+	if err := r.db.Select(&types, "SELECT name, values FROM tag_types ORDER BY name"); err != nil {
+		return nil, err
+	}
+
+	return types, nil
+}
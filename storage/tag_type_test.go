@@ -0,0 +1,18 @@
+package storage_test
+
+import (
+	"testing"
+
+	. "github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagTypeRepository_List(t *testing.T) {
+	loadFixtures(t)
+
+	r := NewTagTypeRepository(db)
+	types, err := r.List()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []TagType{{Name: "faction", Values: []string{"north", "south"}}}, types)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/battleground.go/tagtype"
+)
+
+type SyncCmd struct {
+	Dir    string `arg:"" type:"path" help:"Content directory, with characters/ and skills/ subdirectories."`
+	DryRun bool   `help:"Report the changes without applying them."`
+}
+
+func (cmd SyncCmd) Run(globals *Globals) error {
+	db, err := globals.connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tagTypes, err := tagtype.Open(globals.TagTypes, storage.NewTagTypeRepository(db))
+	if err != nil {
+		return err
+	}
+	tagTypes.Register()
+
+	changes, err := storage.NewSyncer(db, storage.NewDirectory(cmd.Dir)).Sync(cmd.DryRun)
+	if err != nil {
+		return err
+	}
+
+	var conflicts int
+	for _, change := range changes {
+		action := string(change.Action)
+		if change.Deleted {
+			action += " (deleted)"
+		}
+		fmt.Printf("%-20s %s/%d\n", action, change.Table, change.ID)
+		if change.Action == storage.SyncConflict {
+			conflicts++
+		}
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d conflict(s), resolve them on either side and sync again", conflicts)
+	}
+
+	return nil
+}
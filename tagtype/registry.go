@@ -0,0 +1,206 @@
+package tagtype
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	"github.com/farseeingnorthwest/playground/battlefield/v2"
+	"github.com/farseeingnorthwest/playground/battlefield/v2/examples"
+	"go.uber.org/fx"
+	"gopkg.in/yaml.v3"
+)
+
+var Module = fx.Module(
+	"tagtype",
+	fx.Provide(New),
+)
+
+// builtins are the tag types decoded by Go types of their own.
+var builtins = map[string]any{
+	"element": examples.Element(0),
+}
+
+// Enum is a tag of an enumerated tag type, such as
+//
+//	{"_kind": "faction", "value": "north"}
+type Enum struct {
+	Kind  string `json:"_kind"`
+	Value string `json:"value"`
+}
+
+// Registry knows the tag types reactors and characters may be tagged with:
+// the builtin ones, and the enumerated ones defined by content. The zero
+// value only knows the builtin ones.
+type Registry struct {
+	types []storage.TagType
+}
+
+type Params struct {
+	fx.In
+
+	Path string `name:"tag-types"`
+	Repo *storage.TagTypeRepository
+}
+
+func New(params Params) (*Registry, error) {
+	return Open(params.Path, params.Repo)
+}
+
+// Open collects the tag types defined in the YAML file at path, if any, and
+// in the tag_types table.
+func Open(path string, repo *storage.TagTypeRepository) (*Registry, error) {
+	var types []storage.TagType
+	if path != "" {
+		var err error
+		if types, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := repo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRegistry(append(types, stored...)...)
+}
+
+// Load reads tag types from a YAML document such as
+//
+//   - name: faction
+//     values: [north, south]
+func Load(path string) ([]storage.TagType, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		Name   string   `yaml:"name"`
+		Values []string `yaml:"values"`
+	}
+	if err := yaml.Unmarshal(b, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	types := make([]storage.TagType, len(docs))
+	for i, doc := range docs {
+		types[i] = storage.TagType{Name: doc.Name, Values: doc.Values}
+	}
+
+	return types, nil
+}
+
+// NewRegistry checks that the tag types are named uniquely, and that their
+// values are neither missing nor repeated.
+func NewRegistry(types ...storage.TagType) (*Registry, error) {
+	names := make(map[string]bool)
+	for _, t := range types {
+		if t.Name == "" {
+			return nil, fmt.Errorf("tag type with no name")
+		}
+		if _, ok := builtins[t.Name]; ok || names[t.Name] {
+			return nil, fmt.Errorf("tag type %q is defined twice", t.Name)
+		}
+		names[t.Name] = true
+
+		if len(t.Values) == 0 {
+			return nil, fmt.Errorf("tag type %q has no value", t.Name)
+		}
+		values := make(map[string]bool)
+		for _, v := range t.Values {
+			if values[v] {
+				return nil, fmt.Errorf("tag type %q has value %q twice", t.Name, v)
+			}
+			values[v] = true
+		}
+	}
+
+	types = append([]storage.TagType(nil), types...)
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+
+	return &Registry{types}, nil
+}
+
+// Builtins are the names of the builtin tag types, in order.
+func (r *Registry) Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Types are the enumerated tag types, in name order.
+func (r *Registry) Types() []storage.TagType {
+	return r.types
+}
+
+// Names are the names of all the tag types, the builtin ones first.
+func (r *Registry) Names() []string {
+	names := r.Builtins()
+	for _, t := range r.types {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+// Register makes all the tag types known to the battlefield, so that
+// reactors and characters tagged with them can be decoded.
+func (r *Registry) Register() {
+	for name, prototype := range builtins {
+		battlefield.RegisterTagType(name, prototype)
+	}
+	for _, t := range r.types {
+		battlefield.RegisterTagType(t.Name, Enum{})
+	}
+}
+
+// Check looks for tags of enumerated types in a decoded JSON document, and
+// reports those whose value is not one of their type.
+func (r *Registry) Check(doc any) []string {
+	var problems []string
+	reactor.Walk(doc, func(path string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		kind, ok := m["_kind"].(string)
+		if !ok {
+			return
+		}
+		t, ok := r.lookup(kind)
+		if !ok {
+			return
+		}
+
+		value, _ := m["value"].(string)
+		for _, u := range t.Values {
+			if u == value {
+				return
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s: %s has no value %q", path, kind, value))
+	})
+
+	return problems
+}
+
+func (r *Registry) lookup(name string) (storage.TagType, bool) {
+	i := sort.Search(len(r.types), func(i int) bool {
+		return r.types[i].Name >= name
+	})
+	if i < len(r.types) && r.types[i].Name == name {
+		return r.types[i], true
+	}
+
+	return storage.TagType{}, false
+}
@@ -0,0 +1,48 @@
+package tagtype_test
+
+import (
+	"testing"
+
+	"github.com/farseeingnorthwest/battleground.go/reactor"
+	"github.com/farseeingnorthwest/battleground.go/storage"
+	. "github.com/farseeingnorthwest/battleground.go/tagtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRegistry(t *testing.T) {
+	for _, tt := range []struct {
+		types []storage.TagType
+		err   string
+	}{
+		{[]storage.TagType{{Name: "faction", Values: []string{"north"}}, {Name: "class", Values: []string{"mage"}}}, ""},
+		{[]storage.TagType{{Name: "faction", Values: []string{"north"}}, {Name: "faction", Values: []string{"south"}}}, `tag type "faction" is defined twice`},
+		{[]storage.TagType{{Name: "element", Values: []string{"fire"}}}, `tag type "element" is defined twice`},
+		{[]storage.TagType{{Name: "faction"}}, `tag type "faction" has no value`},
+		{[]storage.TagType{{Name: "faction", Values: []string{"north", "north"}}}, `tag type "faction" has value "north" twice`},
+	} {
+		t.Run(tt.err, func(t *testing.T) {
+			_, err := NewRegistry(tt.types...)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRegistry_Check(t *testing.T) {
+	r, err := NewRegistry(storage.TagType{Name: "faction", Values: []string{"north", "south"}})
+	assert.NoError(t, err)
+
+	doc, err := reactor.Unmarshal([]byte(`{
+		"tags": [{"_kind": "faction", "value": "south"}, {"_kind": "label", "text": "Sleep"}],
+		"respond": {"then": {"_kind": "buff", "tags": [{"_kind": "faction", "value": "east"}, {"_kind": "faction"}]}}
+	}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`$.respond.then.tags[0]: faction has no value "east"`,
+		`$.respond.then.tags[1]: faction has no value ""`,
+	}, r.Check(doc))
+}